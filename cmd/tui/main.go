@@ -2,16 +2,82 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
 	"systemctltui/internal/tui" // Import your main TUI package
 )
 
 func main() {
+	backendFlag := flag.String("backend", string(system.BackendSystemctl), "service manager backend: systemctl or dbus")
+	dbusAddress := flag.String("dbus-address", "", "D-Bus address for the dbus backend (default: system bus)")
+	loadTimeout := flag.Duration("load-timeout", 15*time.Second, "give up loading the unit list at startup after this long")
+	userScope := flag.Bool("user", false, "manage the per-user service manager (systemctl --user)")
+	var host, machine string
+	flag.StringVar(&host, "H", "", "operate on a remote host over SSH (systemctl -H [user@]host)")
+	flag.StringVar(&host, "host", "", "same as -H")
+	flag.StringVar(&machine, "M", "", "operate on a local container (systemctl -M [user@]container)")
+	flag.StringVar(&machine, "machine", "", "same as -M")
+	targetsFlag := flag.String("targets", "", "comma-separated targets for the target picker and fleet view (user@host or machine:name)")
+	fleetWorkers := flag.Int("fleet-workers", 4, "maximum concurrent fetches in the fleet view")
+	maxJobs := flag.Int("max-jobs", 4, "maximum commands running at once; more are queued")
+	commandTimeout := flag.Duration("command-timeout", 2*time.Minute, "kill commands run from the UI after this long (0 disables)")
+	refresh := flag.Duration("refresh", 5*time.Second, "unit list refresh interval when live updates are unavailable (0 disables)")
+	flag.Parse()
+
+	// Commands are run through the real os/exec executor
+	executor := system.NewExecExecutor()
+
+	opts := system.Options{
+		Backend:     system.BackendKind(*backendFlag),
+		DBusAddress: *dbusAddress,
+	}
+	if *userScope {
+		opts.Scope = system.ScopeUser
+	}
+	if host != "" && machine != "" {
+		fmt.Fprintln(os.Stderr, "Error: -H and -M cannot be used together")
+		os.Exit(2)
+	}
+	opts.Target = system.Target{Host: host, Machine: machine}
+
+	var targets []system.Target
+	for _, s := range strings.Split(*targetsFlag, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		t, err := system.ParseTarget(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --targets: %v\n", err)
+			os.Exit(2)
+		}
+		targets = append(targets, t)
+	}
+
+	backend, err := system.Open(opts, executor)
+	if err != nil {
+		// Fall back to systemctl rather than refusing to start
+		fmt.Fprintf(os.Stderr, "Warning: %v; falling back to systemctl backend\n", err)
+		opts.Backend = system.BackendSystemctl
+		backend = system.NewSystemctlBackend(executor, opts)
+	}
+
 	// Create a new instance of your TUI model
-	initialModel := tui.NewModel()
+	initialModel := tui.NewModel(executor, backend, tui.Config{
+		Options:         opts,
+		Targets:         targets,
+		FleetWorkers:    *fleetWorkers,
+		RefreshInterval: *refresh,
+		LoadTimeout:     *loadTimeout,
+		CommandTimeout:  *commandTimeout,
+		MaxJobs:         *maxJobs,
+	})
 
 	// Create and start the bubbletea program
 	p := tea.NewProgram(
@@ -20,8 +86,13 @@ func main() {
 		// tea.WithMouseCellMotion(), // Optional: enable mouse support
 	)
 
-	// Run the program
-	if err := p.Start(); err != nil {
+	// Run the program. The user may have switched backends meanwhile, so
+	// close the one the final model holds rather than the startup one.
+	final, err := p.Run()
+	if c, ok := final.(io.Closer); ok {
+		_ = c.Close()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
@@ -6,4 +6,6 @@ const (
 	TabOptions = iota
 	TabCommands
 	TabUnits
+	TabJobs
+	TabFailed
 )
@@ -3,30 +3,51 @@ package listui
 
 import (
 	"fmt"
-	"log"
+	"io"
 	//"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	//"github.com/charmbracelet/lipgloss" // Needed for list styles
+	"systemctltui/internal/styles"
 	"systemctltui/internal/system"
 )
 
 // ListItem implements list.Item and holds a system.Unit.
 // Exported because it's used in tui/model.
 type ListItem struct {
-	Unit system.Unit // Embed the Unit data - system.Unit is already exported
+	Unit    system.Unit // Embed the Unit data - system.Unit is already exported
+	Changed bool        // State changed on the last refresh; rendered highlighted
+	Marked  bool        // Selected for a batch action; rendered with a check mark
 }
 
-// Title returns the unit name for the list item title.
-func (i ListItem) Title() string { return i.Unit.Name }
+// MarkPrefix is shown before the title of marked units.
+const MarkPrefix = "✔ "
+
+// Title returns the unit name for the list item title,
+// prefixed with its host in the fleet view and a mark if selected.
+func (i ListItem) Title() string {
+	title := i.Unit.Name
+	if i.Unit.Host != "" {
+		title = i.Unit.Host + ": " + title
+	}
+	if i.Marked {
+		title = MarkPrefix + title
+	}
+	return title
+}
 
 // Description returns a formatted string of unit status and description for the list item description.
 func (i ListItem) Description() string {
 	return fmt.Sprintf("[%s/%s/%s] %s", i.Unit.Load, i.Unit.Active, i.Unit.Sub, i.Unit.Description)
 }
 
-// FilterValue returns the unit name for filtering.
-func (i ListItem) FilterValue() string { return i.Unit.Name }
+// FilterValue returns the unit name (and host, if any) for filtering.
+func (i ListItem) FilterValue() string {
+	if i.Unit.Host != "" {
+		return i.Unit.Name + " " + i.Unit.Host
+	}
+	return i.Unit.Name
+}
 
 
 // SimpleListItem implements list.Item for static lists (Options, Commands, Filters).
@@ -42,11 +63,73 @@ func (i SimpleListItem) Description() string { return i.DescValue } // <--- Uses
 func (i SimpleListItem) FilterValue() string { return i.TitleValue } // <--- Uses exported field
 
 
+// FacetItem is one value of a unit filter facet (e.g. active state
+// "failed") in the filter dialog, with the number of units that have it.
+type FacetItem struct {
+	Facet    string // "type", "load", "active" or "sub"
+	Value    string
+	Count    int
+	Selected bool
+}
+
+// Title shows the facet, the check box, the value and its count on one line.
+func (i FacetItem) Title() string {
+	box := "[ ]"
+	if i.Selected {
+		box = "[x]"
+	}
+	return fmt.Sprintf("%-7s %s %s (%d)", i.Facet, box, i.Value, i.Count)
+}
+func (i FacetItem) Description() string { return "" }
+func (i FacetItem) FilterValue() string { return i.Facet + " " + i.Value }
+
+// CreateFacetList creates the list of the unit filter dialog, one line per value.
+func CreateFacetList(items []list.Item) list.Model {
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
+
+	l := list.New(items, delegate, 50, 20)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false) // the dialog has its own key hints
+	l.DisableQuitKeybindings() // the dialog closes itself; q must never quit the program
+	return l
+}
+
+// unitDelegate renders ListItems like the default delegate, but switches to
+// highlight styles for units marked Changed.
+type unitDelegate struct {
+	list.DefaultDelegate
+	changedStyles list.DefaultItemStyles
+}
+
+func newUnitDelegate() unitDelegate {
+	d := unitDelegate{DefaultDelegate: list.NewDefaultDelegate()}
+
+	d.changedStyles = list.NewDefaultItemStyles()
+	d.changedStyles.NormalTitle = d.changedStyles.NormalTitle.Foreground(styles.ChangedUnitColor).Bold(true)
+	d.changedStyles.NormalDesc = d.changedStyles.NormalDesc.Foreground(styles.ChangedUnitColor)
+	d.changedStyles.SelectedTitle = d.changedStyles.SelectedTitle.Bold(true)
+	return d
+}
+
+// Render implements list.ItemDelegate.
+func (d unitDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if li, ok := item.(ListItem); ok && li.Changed {
+		highlighted := d.DefaultDelegate
+		highlighted.Styles = d.changedStyles
+		highlighted.Render(w, m, index, item)
+		return
+	}
+	d.DefaultDelegate.Render(w, m, index, item)
+}
+
 // CreateList creates a new bubbletea list model from a slice of ListItems (Units).
 // Exported because it's used in NewLists.
 func CreateList(items []ListItem) list.Model {
 	const width, height = 60, 20 // Example fixed size
-	delegate := list.NewDefaultDelegate()
+	delegate := newUnitDelegate()
 
 	listItems := convert(items)
 	// Provide a placeholder if the list is empty after conversion
@@ -122,63 +205,69 @@ func InitOptionsList() list.Model {
     return CreateSimpleList(items) // Use exported CreateSimpleList
 }
 
-// InitCommandsList creates the list for systemctl commands.
+// InitCommandsList creates the list for systemctl commands from the verb
+// catalogue (system.Verbs); add commands there.
 // Exported because it's used in NewLists.
 func InitCommandsList() list.Model {
-	items := []SimpleListItem{ // Use exported SimpleListItem
-		{TitleValue: "status", DescValue: "Show unit status and logs"}, // Use exported fields
-		{TitleValue: "start", DescValue: "Start one or more units"}, // Use exported fields
-		{TitleValue: "stop", DescValue: "Stop one or more units"}, // Use exported fields
-		{TitleValue: "restart", DescValue: "Restart one or more units"}, // Use exported fields
-		{TitleValue: "enable", DescValue: "Enable one or more units"}, // Use exported fields
-		{TitleValue: "disable", DescValue: "Disable one or more units"}, // Use exported fields
-		// Add more commands here
+	items := make([]SimpleListItem, 0, len(system.Verbs))
+	for _, v := range system.Verbs {
+		desc := v.Description
+		if v.Destructive {
+			desc += " [destructive]"
+		}
+		items = append(items, SimpleListItem{TitleValue: v.Name, DescValue: desc})
 	}
-    return CreateSimpleList(items) // Use exported CreateSimpleList
+	l := CreateSimpleList(items) // Use exported CreateSimpleList
+	l.SetShowPagination(true)    // The catalogue spans several pages
+	return l
 }
 
 
-// InitUnitsList fetches units from the system and creates the list of ListItems (Units).
+// InitUnitsList creates the (initially empty) list for the Units tab.
+// Units are loaded asynchronously after startup and filled in with SetItems.
 // Exported because it's used in NewLists.
 func InitUnitsList() list.Model {
-	// Fetch units from the system
-	units, err := system.FetchUnits() // system.FetchUnits is already exported
-	if err != nil {
-		log.Printf("Error fetching units: %v", err)
-		// Create a list item to show the error
-        // Using the Unit struct for consistency, even for errors
-        errorUnit := system.Unit{ // system.Unit is already exported
-            Name: "Error",
-            Description: fmt.Sprintf("Failed to fetch units: %v", err),
-            Load: "error", Active: "error", Sub: "error", Type: "error",
-        }
-		return CreateList([]ListItem{{Unit: errorUnit}}) // Use exported CreateList and ListItem
-	}
+	l := CreateList(nil) // Use exported CreateList
+	l.SetItems(nil)      // Drop the "No units found" placeholder while loading
+	return l
+}
 
-	// Convert fetched Units to ListItems
-	items := make([]ListItem, 0, len(units)) // Use exported ListItem
+// UnitItems converts fetched Units to list items for the Units tab,
+// flagging those whose Key is in marked.
+// Exported because it's used in tui when units are (re)loaded.
+func UnitItems(units []system.Unit, marked map[string]bool) []list.Item {
+	items := make([]list.Item, 0, len(units))
 	for _, unit := range units {
-		items = append(items, ListItem{Unit: unit}) // Use exported ListItem
+		items = append(items, ListItem{Unit: unit, Marked: marked[unit.Key()]}) // Use exported ListItem
 	}
+	return items
+}
 
-	// Create and return the initial list.Model containing all units
-	return CreateList(items) // Use exported CreateList
+// InitJobsList creates the (initially empty) list of the Jobs tab.
+func InitJobsList() list.Model {
+	l := CreateSimpleList(nil)
+	l.SetFilteringEnabled(false)
+	return l
 }
 
-// NewLists initializes all the necessary lists for the application.
-// It fetches units (which are stored in the model afterwards)
+// InitFailedList creates the list of the Failed tab, filled from the
+// Units tab's data.
+func InitFailedList() list.Model {
+	l := CreateList(nil)
+	l.SetItems(nil)
+	return l
+}
+
+// NewLists initializes all the necessary lists for the application
 // and returns the initial list models for the tabs.
+// Units are not fetched here; see InitUnitsList.
 // Exported because it's used in tui/model.
 func NewLists() []list.Model { // <--- Exported function name
-    // InitUnitsList returns the initial list model for the Units tab.
-    // The full list of units fetched by InitUnitsList will be extracted
-    // and stored separately in the model in NewModel.
-	unitsListModel := InitUnitsList() // InitUnitsList is exported
-
 	return []list.Model{
 		InitOptionsList(),    // InitOptionsList is exported
 		InitCommandsList(),   // InitCommandsList is exported
-		unitsListModel,       // The list.Model itself is a type from an external package
+		InitUnitsList(),      // Filled in once the initial load finishes
+		InitJobsList(),       // Filled in as commands are run
+		InitFailedList(),     // Filled in with the failed units
 	}
 }
-
@@ -1,13 +1,55 @@
 // package messages
 package messages
 
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
 //import "fmt" // Often useful for error messages
 
+// CommandStatus says how a command ended.
+type CommandStatus int
+
+const (
+	CommandCompleted CommandStatus = iota // ran to completion (successfully or not; see Err)
+	CommandCancelled                      // cancelled by the user
+	CommandTimedOut                       // killed after its timeout
+)
+
+// String returns "completed", "cancelled" or "timed out".
+func (s CommandStatus) String() string {
+	switch s {
+	case CommandCancelled:
+		return "cancelled"
+	case CommandTimedOut:
+		return "timed out"
+	default:
+		return "completed"
+	}
+}
+
 // CommandFinishedMsg is a custom message sent when a system command finishes.
+// Its output has already been delivered line by line as CommandOutputMsgs.
 type CommandFinishedMsg struct {
-	Output string // The command's standard output and standard error
-	Err    error  // Any error that occurred during execution
+	Err    error         // Any error that occurred during execution
+	Status CommandStatus // whether it completed, was cancelled or timed out
+
+	ID       int           // the ID the command was started with
+	ExitCode int           // the process exit status, if it ran to completion
+	Elapsed  time.Duration // how long the command ran
+}
+
+// CommandOutputMsg carries one line of output from a streaming command.
+// Next reads the following line (or the final CommandFinishedMsg); it must
+// be returned from Update until the command finishes, or the command blocks.
+type CommandOutputMsg struct {
+	ID     int    // the ID the command was started with
+	Line   string // the line, without its newline
+	Stderr bool   // true if the line was written to stderr
+	Next   tea.Cmd
 }
 
 // Add other application-level messages here in the future if needed.
-// type SomeOtherAppMsg struct { ... }
\ No newline at end of file
+// type SomeOtherAppMsg struct { ... }
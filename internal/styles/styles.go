@@ -2,8 +2,6 @@
 package styles
 
 import (
-	"strings" // fmt is removed
-
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -31,27 +29,113 @@ var (
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(lipgloss.Color("#5A56E0"))
 
+	// ScopeBadgeStyle marks the service manager scope in the tab bar.
+	ScopeBadgeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#5A56E0")).
+			Padding(0, 1).
+			MarginLeft(2)
+
+	// ChangedUnitColor highlights units whose state changed on the last refresh.
+	ChangedUnitColor = lipgloss.Color("#E5C07B")
+
+	// DetailPaneStyle frames the unit detail pane next to the Units list.
+	DetailPaneStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#5A56E0")).
+			Padding(0, 1)
+
+	// SearchMatchStyle highlights search matches in the output pager.
+	SearchMatchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#1E1E1E")).
+			Background(lipgloss.Color("#A1A1A1"))
+
+	// SearchCurrentStyle highlights the match the pager jumped to.
+	SearchCurrentStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#1E1E1E")).
+				Background(lipgloss.Color("#E5C07B"))
+
+	// ChipStyle shows an active unit filter facet above the Units list.
+	ChipStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#3E4451")).
+			Padding(0, 1).
+			MarginRight(1)
+
+	// WarningStyle flags destructive commands in the preview.
+	WarningStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#E06C75"))
+
 	// FooterStyle for the help/info text at the bottom.
 	FooterStyle = lipgloss.NewStyle().
 			PaddingTop(1).
 			Foreground(lipgloss.Color("#A1A1A1"))
 )
 
+// Journal entries are coloured by syslog priority, like journalctl does.
+var (
+	priorityErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#E06C75")).Bold(true) // emerg, alert, crit, err
+	priorityWarningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#E5C07B"))            // warning
+	priorityNoticeStyle  = lipgloss.NewStyle().Bold(true)                                       // notice
+	priorityInfoStyle    = lipgloss.NewStyle()                                                  // info
+	priorityDebugStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#7F848E"))            // debug
+)
+
+// PriorityStyle returns the style for a journal entry of the given syslog
+// priority (0 emerg to 7 debug).
+func PriorityStyle(priority int) lipgloss.Style {
+	switch {
+	case priority <= 3:
+		return priorityErrorStyle
+	case priority == 4:
+		return priorityWarningStyle
+	case priority == 5:
+		return priorityNoticeStyle
+	case priority == 6:
+		return priorityInfoStyle
+	default:
+		return priorityDebugStyle
+	}
+}
+
+// stateRunningStyle marks a healthy manager in the health header.
+var stateRunningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#98C379")).Bold(true)
 
-// RenderTabs renders the tab bar string.
-func RenderTabs(tabs []string, active int) string {
-	var b strings.Builder
+// SystemStateStyle returns the style for a manager state from
+// 'systemctl is-system-running' in the health header.
+func SystemStateStyle(state string) lipgloss.Style {
+	switch state {
+	case "running":
+		return stateRunningStyle
+	case "degraded", "offline":
+		return priorityErrorStyle
+	case "maintenance", "starting", "stopping", "initializing":
+		return priorityWarningStyle
+	default:
+		return priorityDebugStyle
+	}
+}
+
+// RenderTabs renders the tab bar string, followed by the scope badge
+// (e.g. "system" or "user") naming the service manager being managed.
+func RenderTabs(tabs []string, active int, scope string) string {
+	// The active tab is two lines tall (text + underline), so the pieces are
+	// joined side by side rather than concatenated as strings.
+	var parts []string
 	for i, t := range tabs {
 		style := TabInactiveStyle
 		if i == active {
 			style = TabActiveStyle
 		}
 		// Added a space inside the active tab rendering for visual padding if desired
-		// b.WriteString(style.Render(fmt.Sprintf(" %s ", t))) // Example with padding
-		b.WriteString(style.Render(t)) // Render the text with the chosen style
-		b.WriteString(" ") // Space between tabs
+		// parts = append(parts, style.Render(fmt.Sprintf(" %s ", t))) // Example with padding
+		parts = append(parts, style.Render(t), " ") // Render the text with the chosen style, then a space between tabs
+	}
+	if scope != "" {
+		parts = append(parts, ScopeBadgeStyle.Render(scope))
 	}
-	return b.String()
+	return lipgloss.JoinHorizontal(lipgloss.Top, parts...)
 }
 
 // You can add other rendering helper functions here
@@ -0,0 +1,138 @@
+// package system
+package system
+
+import (
+	"context"
+	"fmt"
+)
+
+// Backend is the API the TUI uses to talk to a service manager.
+// There are two implementations: SystemctlBackend, which shells out to
+// systemctl through an Executor, and DBusBackend, which calls the systemd
+// manager directly over D-Bus.
+type Backend interface {
+	// Name identifies the backend in the UI (e.g. "systemctl", "dbus").
+	Name() string
+	// ListUnits returns every unit currently known to the manager.
+	ListUnits(ctx context.Context) ([]Unit, error)
+	// Run executes a systemctl-style argument vector, verb first
+	// (e.g. "restart", "nginx.service"), and returns human-readable output.
+	Run(ctx context.Context, args ...string) (string, error)
+	// Stream is like Run but delivers the output line by line as it is
+	// produced, for long-running commands.
+	Stream(ctx context.Context, args ...string) (Stream, error)
+	// UnitDetails returns the full property set of one unit
+	// (the equivalent of 'systemctl show UNIT').
+	UnitDetails(ctx context.Context, unit string) (UnitDetails, error)
+	// ListJobs returns the jobs queued in the manager itself
+	// (the equivalent of 'systemctl list-jobs').
+	ListJobs(ctx context.Context) ([]ManagerJob, error)
+	// Close releases any connection held by the backend.
+	Close() error
+}
+
+// BackendKind selects which Backend implementation Open returns.
+type BackendKind string
+
+const (
+	BackendSystemctl BackendKind = "systemctl" // shell out to systemctl (default)
+	BackendDBus      BackendKind = "dbus"      // talk to org.freedesktop.systemd1 directly
+)
+
+// Scope selects which service manager instance a backend talks to.
+type Scope int
+
+const (
+	ScopeSystem Scope = iota // the system manager (PID 1)
+	ScopeUser                // the calling user's manager (systemctl --user)
+)
+
+// String returns "system" or "user".
+func (s Scope) String() string {
+	if s == ScopeUser {
+		return "user"
+	}
+	return "system"
+}
+
+// Options configures the backend chosen at startup.
+type Options struct {
+	Backend BackendKind
+	Scope   Scope
+	Target  Target // remote host or container; the zero value is local
+	// DBusAddress overrides the bus the D-Bus backend connects to, e.g. the
+	// address of a private dbus-daemon running a stub manager.
+	DBusAddress string
+}
+
+// Flags returns the systemctl global flags selecting the configured manager,
+// e.g. ["--user", "-H", "root@web1"]. They go before the verb on every invocation.
+func (o Options) Flags() []string {
+	var flags []string
+	if o.Scope == ScopeUser {
+		flags = append(flags, "--user")
+	}
+	return append(flags, o.Target.Flags()...)
+}
+
+// Open creates the backend described by opts. Commands that have to be run
+// as processes (including the D-Bus backend's fallbacks) go through ex.
+func Open(opts Options, ex Executor) (Backend, error) {
+	switch opts.Backend {
+	case "", BackendSystemctl:
+		return NewSystemctlBackend(ex, opts), nil
+	case BackendDBus:
+		return NewDBusBackend(opts, ex)
+	default:
+		return nil, fmt.Errorf("unknown backend %q (want %q or %q)", opts.Backend, BackendSystemctl, BackendDBus)
+	}
+}
+
+// SystemctlBackend implements Backend by running the systemctl binary.
+type SystemctlBackend struct {
+	exec  Executor
+	flags []string // global flags from Options.Flags, prepended to every call
+}
+
+// NewSystemctlBackend returns a Backend that runs systemctl through ex
+// against the manager selected by opts.
+func NewSystemctlBackend(ex Executor, opts Options) *SystemctlBackend {
+	return &SystemctlBackend{exec: ex, flags: opts.Flags()}
+}
+
+// Name implements Backend.
+func (b *SystemctlBackend) Name() string { return string(BackendSystemctl) }
+
+// ListUnits implements Backend.
+func (b *SystemctlBackend) ListUnits(ctx context.Context) ([]Unit, error) {
+	return FetchUnits(ctx, b.exec, b.flags...)
+}
+
+// Run implements Backend.
+func (b *SystemctlBackend) Run(ctx context.Context, args ...string) (string, error) {
+	argv := append(append([]string{}, b.flags...), args...)
+	res, err := b.exec.RunContext(ctx, "systemctl", argv...)
+	return combineOutput(res, err), err
+}
+
+// Stream implements Backend.
+func (b *SystemctlBackend) Stream(ctx context.Context, args ...string) (Stream, error) {
+	argv := append(append([]string{}, b.flags...), args...)
+	return b.exec.Stream(ctx, "systemctl", argv...)
+}
+
+// Close implements Backend.
+func (b *SystemctlBackend) Close() error { return nil }
+
+// combineOutput merges stdout and stderr of res into one display string.
+func combineOutput(res Result, err error) string {
+	output := string(res.Stdout)
+	if stderrStr := string(res.Stderr); stderrStr != "" {
+		// Append if there was an error or stderr is not empty
+		if output != "" {
+			output += "\n--- STDERR ---\n" // Separator
+		}
+		output += stderrStr
+	}
+	return output
+}
@@ -2,86 +2,71 @@
 package system
 
 import (
-	"bytes"
-	"os/exec"
+	"context"
+	"errors"
 	//"strings"
-    "fmt" // Needed for fmt.Errorf
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"systemctltui/internal/messages" // <--- Import the new messages package
 )
 
-// ExecuteCommandAsync runs a systemctl command asynchronously and sends a CommandFinishedMsg
-func ExecuteCommandAsync(command string, args ...string) tea.Cmd {
-	return func() tea.Msg {
-		cmd := exec.Command(command, args...)
+// commandStatus tells from ctx whether a command that just returned was
+// cancelled or timed out.
+func commandStatus(ctx context.Context) messages.CommandStatus {
+	switch ctx.Err() {
+	case context.Canceled:
+		return messages.CommandCancelled
+	case context.DeadlineExceeded:
+		return messages.CommandTimedOut
+	default:
+		return messages.CommandCompleted
+	}
+}
 
-		var stdout, stderr bytes.Buffer
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
+// StreamCommandAsync runs a command through ex and delivers its output line
+// by line as CommandOutputMsgs tagged with id, followed by a CommandFinishedMsg
+// with the exit status and elapsed time. The command is killed when ctx is
+// cancelled or times out.
+func StreamCommandAsync(ctx context.Context, id int, ex Executor, command string, args ...string) tea.Cmd {
+	return streamAsync(ctx, id, func() (Stream, error) {
+		return ex.Stream(ctx, command, args...)
+	})
+}
 
-		err := cmd.Run() // This is a blocking call
+// StreamBackendAsync is StreamCommandAsync for a systemctl-style argument
+// vector (verb first) run through b.
+func StreamBackendAsync(ctx context.Context, id int, b Backend, args ...string) tea.Cmd {
+	return streamAsync(ctx, id, func() (Stream, error) {
+		return b.Stream(ctx, args...)
+	})
+}
 
-		output := stdout.String()
-		if stderrStr := stderr.String(); stderrStr != "" {
-			// Append stderr unless it's just a status message on some systems
-            // Simple heuristic: if stderr contains "Active:" or similar status, maybe don't append?
-            // For robustness, let's append it always or make it conditional based on Err
-            if err != nil || stderrStr != "" { // Append if there was an error or stderr is not empty
-               if output != "" {
-                  output += "\n--- STDERR ---\n" // Separator
-               }
-               output += stderrStr
-            }
+// streamAsync starts a stream and reads its first message.
+func streamAsync(ctx context.Context, id int, start func() (Stream, error)) tea.Cmd {
+	return func() tea.Msg {
+		started := time.Now()
+		s, err := start()
+		if err != nil {
+			return messages.CommandFinishedMsg{ID: id, Err: err, Status: commandStatus(ctx), Elapsed: time.Since(started)}
 		}
-
-
-		// Send the result back to the main update loop
-		return messages.CommandFinishedMsg{Output: output, Err: err} // <--- Use messages.CommandFinishedMsg
+		return readStream(ctx, id, s, started)()
 	}
 }
 
-// Helper function to construct the systemctl command for common actions
-func SystemctlCommand(command string, unit string) tea.Cmd {
-    // Basic validation
-    if command == "" {
-        return func() tea.Msg {
-             return messages.CommandFinishedMsg{Output: "", Err: fmt.Errorf("no command specified")} // <--- Use messages.CommandFinishedMsg
-        }
-    }
-
-    args := []string{command}
-
-    // Logic to add the unit argument based on command
-    unitRequired := true // Assume most commands need a unit by default
-    switch command {
-        case "list-units", "list-timers", "list-sockets", "list-jobs", "list-dependencies", "list-unit-files":
-            unitRequired = false // These commands don't take a unit as the *first* argument like start/stop
-        case "status", "is-active", "is-enabled", "is-failed":
-             // These can take a unit, and are much more useful with one,
-             // but 'status' without a unit shows overall system status.
-             // Let's make the unit optional for status, is-active, etc.
-             unitRequired = false // Unit is optional
-             if unit != "" {
-                 args = append(args, unit)
-             }
-         default:
-             // start, stop, restart, enable, disable, mask, unmask, kill, cat, edit, show etc.
-             // These typically require a unit.
-             unitRequired = true
-             if unit != "" {
-                 args = append(args, unit)
-             }
-    }
-
-
-    if unitRequired && unit == "" {
-         // If the command *requires* a unit but none was provided
-          return func() tea.Msg {
-             return messages.CommandFinishedMsg{Output: "", Err: fmt.Errorf("command '%s' requires a unit", command)} // <--- Use messages.CommandFinishedMsg
-        }
-    }
-
-
-	return ExecuteCommandAsync("systemctl", args...)
+// readStream returns a command reading the next line of s, or the final
+// CommandFinishedMsg once s is drained.
+func readStream(ctx context.Context, id int, s Stream, started time.Time) tea.Cmd {
+	return func() tea.Msg {
+		if line, ok := <-s.Lines(); ok {
+			return messages.CommandOutputMsg{ID: id, Line: line.Text, Stderr: line.Stderr, Next: readStream(ctx, id, s, started)}
+		}
+		err := s.Wait()
+		finished := messages.CommandFinishedMsg{ID: id, Err: err, Status: commandStatus(ctx), Elapsed: time.Since(started)}
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			finished.ExitCode = exitErr.Code
+		}
+		return finished
+	}
 }
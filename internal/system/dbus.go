@@ -0,0 +1,428 @@
+// package system
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdBusName      = "org.freedesktop.systemd1"
+	systemdObjectPath   = dbus.ObjectPath("/org/freedesktop/systemd1")
+	systemdManagerIface = "org.freedesktop.systemd1.Manager"
+)
+
+// Job is a job queued by the systemd manager, e.g. in response to StartUnit.
+type Job struct {
+	Path dbus.ObjectPath // e.g. /org/freedesktop/systemd1/job/1234
+	Unit string
+	Verb string // the action that queued the job ("start", "stop", ...)
+}
+
+// UnitFileChange describes one change made by EnableUnitFiles and friends.
+type UnitFileChange struct {
+	Type        string // "symlink" or "unlink"
+	Filename    string
+	Destination string
+}
+
+// ManagerError is an error reply from the systemd manager, carrying the
+// D-Bus error name (e.g. "org.freedesktop.systemd1.NoSuchUnit").
+type ManagerError struct {
+	Name    string
+	Message string
+}
+
+func (e *ManagerError) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return fmt.Sprintf("%s: %s", e.Name, e.Message)
+}
+
+// JobError is a job the manager ran that did not succeed, as reported by
+// its JobRemoved signal.
+type JobError struct {
+	Job    Job
+	Result string // "failed", "timeout", "dependency", "canceled", ...
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("%s job for %s finished with result '%s'", e.Job.Verb, e.Job.Unit, e.Result)
+}
+
+// DBusBackend implements Backend by calling org.freedesktop.systemd1 over D-Bus.
+// Verbs that have no manager method (status, cat, --help, ...) are delegated
+// to a SystemctlBackend.
+type DBusBackend struct {
+	conn     *dbus.Conn
+	manager  dbus.BusObject
+	fallback *SystemctlBackend
+}
+
+// NewDBusBackend connects to the system bus, the session bus for ScopeUser,
+// or to opts.DBusAddress if set.
+func NewDBusBackend(opts Options, ex Executor) (*DBusBackend, error) {
+	if !opts.Target.IsLocal() {
+		return nil, fmt.Errorf("the dbus backend cannot reach %s; use the systemctl backend", opts.Target)
+	}
+
+	var (
+		conn *dbus.Conn
+		err  error
+	)
+	switch {
+	case opts.DBusAddress != "":
+		conn, err = dbus.Connect(opts.DBusAddress)
+	case opts.Scope == ScopeUser:
+		conn, err = dbus.ConnectSessionBus()
+	default:
+		conn, err = dbus.ConnectSystemBus()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to D-Bus: %w", err)
+	}
+
+	return &DBusBackend{
+		conn:     conn,
+		manager:  conn.Object(systemdBusName, systemdObjectPath),
+		fallback: NewSystemctlBackend(ex, opts),
+	}, nil
+}
+
+// Name implements Backend.
+func (b *DBusBackend) Name() string { return string(BackendDBus) }
+
+// Close implements Backend.
+func (b *DBusBackend) Close() error { return b.conn.Close() }
+
+// ListUnits implements Backend using Manager.ListUnits.
+func (b *DBusBackend) ListUnits(ctx context.Context) ([]Unit, error) {
+	var raw []struct {
+		Name        string
+		Description string
+		LoadState   string
+		ActiveState string
+		SubState    string
+		Following   string
+		Path        dbus.ObjectPath
+		JobID       uint32
+		JobType     string
+		JobPath     dbus.ObjectPath
+	}
+	if err := b.call(ctx, "ListUnits").Store(&raw); err != nil {
+		return nil, managerError(err)
+	}
+
+	units := make([]Unit, 0, len(raw))
+	for _, u := range raw {
+		units = append(units, Unit{
+			Name:        u.Name,
+			Load:        u.LoadState,
+			Active:      u.ActiveState,
+			Sub:         u.SubState,
+			Description: u.Description,
+			Type:        unitTypeFromName(u.Name),
+		})
+	}
+	return units, nil
+}
+
+// Run implements Backend. The verb is mapped onto the matching manager
+// method, and jobs are waited for (see runJobs); anything without one, or
+// with options (--no-block, --signal=...), is run through systemctl instead.
+func (b *DBusBackend) Run(ctx context.Context, args ...string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("no command specified")
+	}
+	if hasOptions(args[1:]) {
+		return b.fallback.Run(ctx, args...)
+	}
+	verb, units := args[0], normalizeUnitNames(operands(args[1:]))
+
+	switch verb {
+	case "start", "stop", "restart", "reload", "try-restart", "reload-or-restart":
+		return b.runJobs(ctx, verb, units)
+
+	case "enable", "mask":
+		var (
+			changes []UnitFileChange
+			err     error
+		)
+		if verb == "enable" {
+			_, changes, err = b.EnableUnitFiles(ctx, units, false, false)
+		} else {
+			changes, err = b.MaskUnitFiles(ctx, units, false, false)
+		}
+		if err != nil {
+			return "", err
+		}
+		return formatChanges(changes), b.Reload(ctx)
+
+	case "disable", "unmask":
+		var (
+			changes []UnitFileChange
+			err     error
+		)
+		if verb == "disable" {
+			changes, err = b.DisableUnitFiles(ctx, units, false)
+		} else {
+			changes, err = b.UnmaskUnitFiles(ctx, units, false)
+		}
+		if err != nil {
+			return "", err
+		}
+		return formatChanges(changes), b.Reload(ctx)
+
+	case "reset-failed":
+		if len(units) == 0 {
+			return "", managerError(b.call(ctx, "ResetFailed").Err)
+		}
+		for _, unit := range units {
+			if err := b.ResetFailedUnit(ctx, unit); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+
+	case "kill":
+		for _, unit := range units {
+			if err := b.KillUnit(ctx, unit, "all", syscall.SIGTERM); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+	}
+
+	return b.fallback.Run(ctx, args...)
+}
+
+// nativeVerbs are the verbs Run maps onto manager methods.
+var nativeVerbs = map[string]bool{
+	"start": true, "stop": true, "restart": true, "reload": true, "try-restart": true, "reload-or-restart": true,
+	"enable": true, "disable": true, "mask": true, "unmask": true,
+	"reset-failed": true, "kill": true,
+}
+
+// Stream implements Backend. Manager method calls return all at once, so
+// their output arrives as a single batch; the systemctl fallback streams.
+func (b *DBusBackend) Stream(ctx context.Context, args ...string) (Stream, error) {
+	if len(args) > 0 && (!nativeVerbs[args[0]] || hasOptions(args[1:])) {
+		return b.fallback.Stream(ctx, args...)
+	}
+	output, err := b.Run(ctx, args...)
+	return newDoneStream(output, err), nil
+}
+
+// StartUnit queues a start job for unit.
+func (b *DBusBackend) StartUnit(ctx context.Context, unit, mode string) (Job, error) {
+	return b.jobCall(ctx, "StartUnit", "start", unit, mode)
+}
+
+// StopUnit queues a stop job for unit.
+func (b *DBusBackend) StopUnit(ctx context.Context, unit, mode string) (Job, error) {
+	return b.jobCall(ctx, "StopUnit", "stop", unit, mode)
+}
+
+// RestartUnit queues a restart job for unit.
+func (b *DBusBackend) RestartUnit(ctx context.Context, unit, mode string) (Job, error) {
+	return b.jobCall(ctx, "RestartUnit", "restart", unit, mode)
+}
+
+// EnableUnitFiles enables the given unit files. The returned bool reports
+// whether the files carried [Install] information.
+func (b *DBusBackend) EnableUnitFiles(ctx context.Context, files []string, runtime, force bool) (bool, []UnitFileChange, error) {
+	var carriesInstallInfo bool
+	var changes []UnitFileChange
+	err := b.call(ctx, "EnableUnitFiles", files, runtime, force).Store(&carriesInstallInfo, &changes)
+	return carriesInstallInfo, changes, managerError(err)
+}
+
+// DisableUnitFiles disables the given unit files.
+func (b *DBusBackend) DisableUnitFiles(ctx context.Context, files []string, runtime bool) ([]UnitFileChange, error) {
+	var changes []UnitFileChange
+	err := b.call(ctx, "DisableUnitFiles", files, runtime).Store(&changes)
+	return changes, managerError(err)
+}
+
+// MaskUnitFiles masks the given unit files.
+func (b *DBusBackend) MaskUnitFiles(ctx context.Context, files []string, runtime, force bool) ([]UnitFileChange, error) {
+	var changes []UnitFileChange
+	err := b.call(ctx, "MaskUnitFiles", files, runtime, force).Store(&changes)
+	return changes, managerError(err)
+}
+
+// UnmaskUnitFiles unmasks the given unit files.
+func (b *DBusBackend) UnmaskUnitFiles(ctx context.Context, files []string, runtime bool) ([]UnitFileChange, error) {
+	var changes []UnitFileChange
+	err := b.call(ctx, "UnmaskUnitFiles", files, runtime).Store(&changes)
+	return changes, managerError(err)
+}
+
+// ResetFailedUnit clears the failed state of unit.
+func (b *DBusBackend) ResetFailedUnit(ctx context.Context, unit string) error {
+	return managerError(b.call(ctx, "ResetFailedUnit", unit).Err)
+}
+
+// KillUnit sends signal to the processes of unit selected by whom
+// ("main", "control" or "all").
+func (b *DBusBackend) KillUnit(ctx context.Context, unit, whom string, signal syscall.Signal) error {
+	return managerError(b.call(ctx, "KillUnit", unit, whom, int32(signal)).Err)
+}
+
+// Reload asks the manager to reload its configuration (daemon-reload).
+func (b *DBusBackend) Reload(ctx context.Context) error {
+	return managerError(b.call(ctx, "Reload").Err)
+}
+
+// queueJob maps a systemctl job verb onto its manager method.
+func (b *DBusBackend) queueJob(ctx context.Context, verb, unit string) (Job, error) {
+	methods := map[string]string{
+		"start":             "StartUnit",
+		"stop":              "StopUnit",
+		"restart":           "RestartUnit",
+		"reload":            "ReloadUnit",
+		"try-restart":       "TryRestartUnit",
+		"reload-or-restart": "ReloadOrRestartUnit",
+	}
+	return b.jobCall(ctx, methods[verb], verb, unit, "replace")
+}
+
+// runJobs queues a verb job for each unit and, like systemctl without
+// --no-block, waits until they are all finished. A job whose result is not
+// "done" is reported as a *JobError.
+func (b *DBusBackend) runJobs(ctx context.Context, verb string, units []string) (string, error) {
+	// Listen before queueing so that no JobRemoved can be missed
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(systemdObjectPath),
+		dbus.WithMatchInterface(systemdManagerIface),
+		dbus.WithMatchMember("JobRemoved"),
+	}
+	if err := b.conn.AddMatchSignalContext(ctx, match...); err != nil {
+		return "", fmt.Errorf("adding D-Bus match: %w", err)
+	}
+	defer func() { _ = b.conn.RemoveMatchSignal(match...) }()
+	signals := make(chan *dbus.Signal, 16)
+	b.conn.Signal(signals)
+	defer b.conn.RemoveSignal(signals)
+
+	var out strings.Builder
+	pending := make(map[dbus.ObjectPath]Job)
+	for _, unit := range units {
+		job, err := b.queueJob(ctx, verb, unit)
+		if err != nil {
+			return out.String(), err
+		}
+		fmt.Fprintf(&out, "Job %s queued: %s %s\n", job.Path, job.Verb, job.Unit)
+		pending[job.Path] = job
+	}
+
+	var failed []error
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return out.String(), ctx.Err()
+		case sig, ok := <-signals:
+			if !ok {
+				return out.String(), fmt.Errorf("D-Bus connection closed while waiting for jobs")
+			}
+			var id uint32
+			var path dbus.ObjectPath
+			var unit, result string
+			if sig.Name != systemdManagerIface+".JobRemoved" || dbus.Store(sig.Body, &id, &path, &unit, &result) != nil {
+				continue
+			}
+			job, ok := pending[path]
+			if !ok {
+				continue // someone else's job
+			}
+			delete(pending, path)
+			if result != "done" {
+				failed = append(failed, &JobError{Job: job, Result: result})
+			}
+		}
+	}
+	return out.String(), errors.Join(failed...)
+}
+
+func (b *DBusBackend) jobCall(ctx context.Context, method, verb, unit, mode string) (Job, error) {
+	job := Job{Unit: unit, Verb: verb}
+	err := b.call(ctx, method, unit, mode).Store(&job.Path)
+	return job, managerError(err)
+}
+
+func (b *DBusBackend) call(ctx context.Context, method string, args ...interface{}) *dbus.Call {
+	return b.manager.CallWithContext(ctx, systemdManagerIface+"."+method, 0, args...)
+}
+
+// managerError converts a D-Bus error reply into a *ManagerError so callers
+// can match on the systemd error name. Other errors are returned unchanged.
+func managerError(err error) error {
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) {
+		me := &ManagerError{Name: dbusErr.Name}
+		if len(dbusErr.Body) > 0 {
+			if msg, ok := dbusErr.Body[0].(string); ok {
+				me.Message = msg
+			}
+		}
+		return me
+	}
+	return err
+}
+
+// hasOptions reports whether args (after the verb) contain an option.
+// Everything after "--" is a unit name, whatever it looks like.
+func hasOptions(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if strings.HasPrefix(arg, "-") {
+			return true
+		}
+	}
+	return false
+}
+
+// operands drops the "--" ending the options from args, once hasOptions
+// has ruled out any other option.
+func operands(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+	return args
+}
+
+// normalizeUnitNames appends ".service" to names without a unit suffix,
+// matching what systemctl does for its arguments.
+func normalizeUnitNames(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		if unitTypeFromName(name) == "unknown" {
+			name += ".service"
+		}
+		out[i] = name
+	}
+	return out
+}
+
+func formatChanges(changes []UnitFileChange) string {
+	var out strings.Builder
+	for _, c := range changes {
+		switch c.Type {
+		case "symlink":
+			fmt.Fprintf(&out, "Created symlink %s → %s.\n", c.Filename, c.Destination)
+		case "unlink":
+			fmt.Fprintf(&out, "Removed %s.\n", c.Filename)
+		default:
+			fmt.Fprintf(&out, "%s %s %s\n", c.Type, c.Filename, c.Destination)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,240 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// busConfig is a minimal private bus that lets anyone own and call anything.
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-BUS Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startBus runs a private dbus-daemon for the test and returns its address.
+// The test is skipped where dbus-daemon is not installed.
+func startBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(config, []byte(fmt.Sprintf(busConfig, filepath.Join(dir, "bus"))), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading the bus address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// stubManager is a systemd manager that finishes each job with the result
+// configured for its unit. Units without a result never finish; unknown
+// units are refused like systemd does.
+type stubManager struct {
+	conn    *dbus.Conn
+	results map[string]string // unit -> JobRemoved result; "" leaves the job running
+
+	mu    sync.Mutex
+	jobs  uint32
+	calls []string // "StartUnit a.service replace", ...
+}
+
+func (s *stubManager) queue(method, unit, mode string) (dbus.ObjectPath, *dbus.Error) {
+	result, known := s.results[unit]
+	if !known {
+		return "", dbus.NewError("org.freedesktop.systemd1.NoSuchUnit", []interface{}{"Unit " + unit + " not found."})
+	}
+	s.mu.Lock()
+	s.jobs++
+	id := s.jobs
+	s.calls = append(s.calls, strings.Join([]string{method, unit, mode}, " "))
+	s.mu.Unlock()
+
+	path := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/systemd1/job/%d", id))
+	if result != "" {
+		// A job of some other client finishing first must not be mistaken for ours
+		go func() {
+			_ = s.conn.Emit(systemdObjectPath, systemdManagerIface+".JobRemoved", uint32(1000+id), dbus.ObjectPath("/org/freedesktop/systemd1/job/other"), unit, "failed")
+			_ = s.conn.Emit(systemdObjectPath, systemdManagerIface+".JobRemoved", id, path, unit, result)
+		}()
+	}
+	return path, nil
+}
+
+func (s *stubManager) StartUnit(unit, mode string) (dbus.ObjectPath, *dbus.Error) {
+	return s.queue("StartUnit", unit, mode)
+}
+
+func (s *stubManager) StopUnit(unit, mode string) (dbus.ObjectPath, *dbus.Error) {
+	return s.queue("StopUnit", unit, mode)
+}
+
+func (s *stubManager) RestartUnit(unit, mode string) (dbus.ObjectPath, *dbus.Error) {
+	return s.queue("RestartUnit", unit, mode)
+}
+
+// GetUnit returns the object path of a unit the stub knows.
+func (s *stubManager) GetUnit(unit string) (dbus.ObjectPath, *dbus.Error) {
+	if _, known := s.results[unit]; !known {
+		return "", dbus.NewError("org.freedesktop.systemd1.NoSuchUnit", []interface{}{"Unit " + unit + " not loaded."})
+	}
+	return unitPath(unit), nil
+}
+
+func (s *stubManager) Subscribe() *dbus.Error   { return nil }
+func (s *stubManager) Unsubscribe() *dbus.Error { return nil }
+
+// unitPath escapes a unit name into its object path like systemd does.
+func unitPath(name string) dbus.ObjectPath {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return systemdUnitPathDir + "/" + dbus.ObjectPath(b.String())
+}
+
+// newStubManager claims the systemd bus name on the bus at address.
+func newStubManager(t *testing.T, address string, results map[string]string) *stubManager {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("connecting the stub manager: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	s := &stubManager{conn: conn, results: results}
+	if err := conn.Export(s, systemdObjectPath, systemdManagerIface); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := conn.RequestName(systemdBusName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("claiming %s: %v (reply %v)", systemdBusName, err, reply)
+	}
+	return s
+}
+
+func TestDBusBackendJobs(t *testing.T) {
+	address := startBus(t)
+	stub := newStubManager(t, address, map[string]string{
+		"a.service":    "done",
+		"b.service":    "done",
+		"bad.service":  "failed",
+		"dep.service":  "dependency",
+		"hang.service": "",
+	})
+	b, err := NewDBusBackend(Options{Backend: BackendDBus, DBusAddress: address}, NewFakeExecutor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	tests := []struct {
+		name    string
+		args    []string
+		results []string // results of the failed jobs, sorted
+		noUnit  bool     // the manager refuses the unit
+		timeout bool     // a job never finishes
+	}{
+		{name: "start", args: []string{"start", "--", "a.service"}},
+		{name: "several units", args: []string{"stop", "--", "a", "b.service"}},
+		{name: "failed", args: []string{"restart", "--", "bad.service"}, results: []string{"failed"}},
+		{name: "one of several failed", args: []string{"start", "--", "a.service", "dep.service", "bad.service"}, results: []string{"dependency", "failed"}},
+		{name: "no such unit", args: []string{"start", "--", "missing.service"}, noUnit: true},
+		{name: "never finishes", args: []string{"start", "--", "hang.service"}, timeout: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			if tt.timeout {
+				ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+			}
+			defer cancel()
+
+			out, err := b.Run(ctx, tt.args...)
+			switch {
+			case tt.noUnit:
+				var me *ManagerError
+				if !errors.As(err, &me) || me.Name != "org.freedesktop.systemd1.NoSuchUnit" {
+					t.Fatalf("got %v, want NoSuchUnit", err)
+				}
+				return
+			case tt.timeout:
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("got %v, want the deadline", err)
+				}
+				return
+			}
+
+			if want := len(tt.args) - 2; strings.Count(out, " queued: ") != want {
+				t.Errorf("output %q, want %d queued jobs", out, want)
+			}
+			var got []string
+			var jobErr *JobError
+			if err != nil {
+				joined, ok := err.(interface{ Unwrap() []error })
+				if !ok {
+					t.Fatalf("unexpected error %v", err)
+				}
+				for _, e := range joined.Unwrap() {
+					if !errors.As(e, &jobErr) {
+						t.Fatalf("not a JobError: %v", e)
+					}
+					got = append(got, jobErr.Result)
+				}
+			}
+			sort.Strings(got) // jobs finish in any order
+			if strings.Join(got, ",") != strings.Join(tt.results, ",") {
+				t.Errorf("failed job results %q, want %q", got, tt.results)
+			}
+		})
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if stub.calls[1] != "StopUnit a.service replace" {
+		t.Errorf("unit names are not normalized: %q", stub.calls[1])
+	}
+}
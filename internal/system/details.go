@@ -0,0 +1,260 @@
+// package system
+package system
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// UnitDetails is the structured view of a unit's properties, as reported by
+// 'systemctl show' or read over D-Bus. Numeric fields are zero and times are
+// the zero time when systemd reports them as unset.
+type UnitDetails struct {
+	Name          string // Id
+	Description   string
+	LoadState     string
+	ActiveState   string
+	SubState      string
+	UnitFileState string // enabled, disabled, static, ...
+	Result        string // success, exit-code, signal, core-dump, timeout, ...
+
+	// Service process state
+	MainPID        int
+	ExecMainCode   int // CLD_EXITED (1), CLD_KILLED (2), CLD_DUMPED (3), ...
+	ExecMainStatus int // exit status or signal number, depending on ExecMainCode
+	NRestarts      int
+
+	// Timestamps of the last state transitions
+	ActiveEnterTimestamp   time.Time
+	ActiveExitTimestamp    time.Time
+	InactiveEnterTimestamp time.Time
+	StateChangeTimestamp   time.Time
+	ExecMainStartTimestamp time.Time
+	ExecMainExitTimestamp  time.Time
+
+	// Where the unit comes from
+	FragmentPath string
+	DropInPaths  []string
+
+	// Resource usage
+	MemoryCurrent uint64 // bytes
+	CPUUsageNSec  uint64 // nanoseconds
+	TasksCurrent  uint64
+
+	// Relationships
+	Triggers    []string
+	TriggeredBy []string
+	Wants       []string
+	Requires    []string
+	After       []string
+	Before      []string
+
+	// Properties holds every property as a raw string, including the ones
+	// not broken out above.
+	Properties map[string]string
+}
+
+// Uptime is how long the unit has been active, or zero if it isn't.
+func (d UnitDetails) Uptime(now time.Time) time.Duration {
+	if d.ActiveState != "active" || d.ActiveEnterTimestamp.IsZero() {
+		return 0
+	}
+	return now.Sub(d.ActiveEnterTimestamp)
+}
+
+// UnitDetails implements the details lookup for SystemctlBackend.
+func (b *SystemctlBackend) UnitDetails(ctx context.Context, unit string) (UnitDetails, error) {
+	argv := append(append([]string{}, b.flags...), "show", "--no-pager", unit)
+	res, err := b.exec.RunContext(ctx, "systemctl", argv...)
+	if err != nil {
+		return UnitDetails{}, fmt.Errorf("systemctl show %s: %w", unit, err)
+	}
+	return DetailsFromProperties(ParseShow(string(res.Stdout))), nil
+}
+
+// UnitDetails implements the details lookup for DBusBackend by reading the
+// unit's properties from its generic interface and its type-specific one
+// (e.g. org.freedesktop.systemd1.Service), like 'systemctl show' does.
+func (b *DBusBackend) UnitDetails(ctx context.Context, unit string) (UnitDetails, error) {
+	unit = normalizeUnitNames([]string{unit})[0]
+	var path dbus.ObjectPath
+	if err := b.call(ctx, "LoadUnit", unit).Store(&path); err != nil {
+		return UnitDetails{}, managerError(err)
+	}
+
+	props := make(map[string]string)
+	obj := b.conn.Object(systemdBusName, path)
+	ifaces := []string{systemdUnitIface, typeIface(unitTypeFromName(unit))}
+	for i, iface := range ifaces {
+		var values map[string]dbus.Variant
+		err := obj.CallWithContext(ctx, propertiesIface+".GetAll", 0, iface).Store(&values)
+		if err != nil {
+			if i == 0 {
+				return UnitDetails{}, managerError(err)
+			}
+			continue // not every unit type has its own interface
+		}
+		for key, v := range values {
+			props[key] = variantString(v)
+		}
+	}
+	return DetailsFromProperties(props), nil
+}
+
+// typeIface is the D-Bus interface holding the properties specific to a
+// unit type, e.g. "service" -> "org.freedesktop.systemd1.Service".
+func typeIface(unitType string) string {
+	return "org.freedesktop.systemd1." + strings.ToUpper(unitType[:1]) + unitType[1:]
+}
+
+// variantString renders a D-Bus property value the way 'systemctl show'
+// prints it, as far as DetailsFromProperties is concerned: string arrays
+// space-separated, timestamps as microseconds, numbers in decimal.
+func variantString(v dbus.Variant) string {
+	switch val := v.Value().(type) {
+	case string:
+		return val
+	case dbus.ObjectPath:
+		return string(val)
+	case []string:
+		return strings.Join(val, " ")
+	case bool:
+		if val {
+			return "yes"
+		}
+		return "no"
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// ParseShow parses 'systemctl show' output into a property map. Values may
+// be empty ("Key=") and may span several lines: a line that does not start
+// with a property name followed by '=' continues the previous value.
+func ParseShow(text string) map[string]string {
+	props := make(map[string]string)
+	var last string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok && isPropertyName(key) {
+			props[key] = value
+			last = key
+			continue
+		}
+		if last != "" {
+			props[last] += "\n" + line
+		}
+	}
+	return props
+}
+
+// isPropertyName reports whether s looks like a systemd property name
+// (letters and digits, starting with an upper-case letter).
+func isPropertyName(s string) bool {
+	if s == "" || s[0] < 'A' || s[0] > 'Z' {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// DetailsFromProperties builds UnitDetails from raw property strings as
+// printed by 'systemctl show'.
+func DetailsFromProperties(props map[string]string) UnitDetails {
+	return UnitDetails{
+		Name:          props["Id"],
+		Description:   props["Description"],
+		LoadState:     props["LoadState"],
+		ActiveState:   props["ActiveState"],
+		SubState:      props["SubState"],
+		UnitFileState: props["UnitFileState"],
+		Result:        props["Result"],
+
+		MainPID:        propInt(props["MainPID"]),
+		ExecMainCode:   propInt(props["ExecMainCode"]),
+		ExecMainStatus: propInt(props["ExecMainStatus"]),
+		NRestarts:      propInt(props["NRestarts"]),
+
+		ActiveEnterTimestamp:   propTime(props["ActiveEnterTimestamp"]),
+		ActiveExitTimestamp:    propTime(props["ActiveExitTimestamp"]),
+		InactiveEnterTimestamp: propTime(props["InactiveEnterTimestamp"]),
+		StateChangeTimestamp:   propTime(props["StateChangeTimestamp"]),
+		ExecMainStartTimestamp: propTime(props["ExecMainStartTimestamp"]),
+		ExecMainExitTimestamp:  propTime(props["ExecMainExitTimestamp"]),
+
+		FragmentPath: props["FragmentPath"],
+		DropInPaths:  propList(props["DropInPaths"]),
+
+		MemoryCurrent: propUint(props["MemoryCurrent"]),
+		CPUUsageNSec:  propUint(props["CPUUsageNSec"]),
+		TasksCurrent:  propUint(props["TasksCurrent"]),
+
+		Triggers:    propList(props["Triggers"]),
+		TriggeredBy: propList(props["TriggeredBy"]),
+		Wants:       propList(props["Wants"]),
+		Requires:    propList(props["Requires"]),
+		After:       propList(props["After"]),
+		Before:      propList(props["Before"]),
+
+		Properties: props,
+	}
+}
+
+func propInt(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+// propUint parses an unsigned property; "[not set]" and UINT64_MAX
+// (systemd's "infinity") both mean unset.
+func propUint(s string) uint64 {
+	n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	if err != nil || n == math.MaxUint64 {
+		return 0
+	}
+	return n
+}
+
+func propList(s string) []string {
+	return strings.Fields(s)
+}
+
+// timestampLayouts are the formats 'systemctl show' uses for timestamps.
+var timestampLayouts = []string{
+	"Mon 2006-01-02 15:04:05 MST",
+	"Mon 2006-01-02 15:04:05.000000 MST", // --timestamp=us
+}
+
+// propTime parses a timestamp property. It accepts the human-readable
+// 'systemctl show' form, "@<unix seconds>" (--timestamp=unix) and plain
+// microseconds since the epoch (the D-Bus representation).
+func propTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "" || s == "n/a" || s == "0":
+		return time.Time{}
+	case strings.HasPrefix(s, "@"):
+		if secs, err := strconv.ParseInt(s[1:], 10, 64); err == nil {
+			return time.Unix(secs, 0)
+		}
+	default:
+		if usec, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return time.UnixMicro(int64(usec))
+		}
+	}
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
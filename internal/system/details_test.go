@@ -0,0 +1,206 @@
+package system
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseShow(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want map[string]string
+	}{
+		{
+			name: "one per line",
+			text: "Id=a.service\nLoadState=loaded\n",
+			want: map[string]string{"Id": "a.service", "LoadState": "loaded"},
+		},
+		{
+			name: "empty value",
+			text: "DropInPaths=\nResult=success",
+			want: map[string]string{"DropInPaths": "", "Result": "success"},
+		},
+		{
+			name: "value with =",
+			text: "Environment=LANG=C PATH=/bin\n",
+			want: map[string]string{"Environment": "LANG=C PATH=/bin"},
+		},
+		{
+			name: "continuation lines",
+			text: "Description=first\n  second\nargv[]=/bin/true\nlower=case\n\nId=a.service\n",
+			want: map[string]string{"Description": "first\n  second\nargv[]=/bin/true\nlower=case\n", "Id": "a.service"},
+		},
+		{
+			name: "text before the first property",
+			text: "garbage\nId=a.service",
+			want: map[string]string{"Id": "a.service"},
+		},
+		{
+			name: "not set",
+			text: "MemoryCurrent=[not set]\n",
+			want: map[string]string{"MemoryCurrent": "[not set]"},
+		},
+		{name: "empty", text: "", want: map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseShow(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPropertyName(t *testing.T) {
+	for s, want := range map[string]bool{
+		"Id":           true,
+		"ExecMainPID":  true,
+		"IOWeight2":    true,
+		"":             false,
+		"lower":        false,
+		"  Indented":   false,
+		"argv[]":       false,
+		"Exec-Start":   false,
+		"2Fast":        false,
+		"With Space":   false,
+		"Description ": false,
+	} {
+		if got := isPropertyName(s); got != want {
+			t.Errorf("isPropertyName(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestPropUint(t *testing.T) {
+	tests := []struct {
+		s    string
+		want uint64
+	}{
+		{"123", 123},
+		{" 42 ", 42},
+		{"0", 0},
+		{"", 0},
+		{"[not set]", 0},
+		{"18446744073709551615", 0}, // UINT64_MAX: infinity
+		{"18446744073709551614", 18446744073709551614},
+		{"-1", 0},
+	}
+	for _, tt := range tests {
+		if got := propUint(tt.s); got != tt.want {
+			t.Errorf("propUint(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPropTime(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"@1704359521", time.Unix(1704359521, 0)},
+		{"1704359521123456", time.UnixMicro(1704359521123456)},
+		{"Thu 2024-01-04 09:12:01 UTC", time.Date(2024, 1, 4, 9, 12, 1, 0, time.UTC)},
+		{"Thu 2024-01-04 09:12:01.123456 UTC", time.Date(2024, 1, 4, 9, 12, 1, 123456000, time.UTC)},
+		{" @1 ", time.Unix(1, 0)},
+		{"", time.Time{}},
+		{"n/a", time.Time{}},
+		{"0", time.Time{}},
+		{"@soon", time.Time{}},
+		{"2024-01-04", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := propTime(tt.s); !got.Equal(tt.want) {
+			t.Errorf("propTime(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDetailsFromProperties(t *testing.T) {
+	props := ParseShow(`Id=nginx.service
+Description=A high performance web server
+ActiveState=active
+MainPID=812
+NRestarts=2
+ActiveEnterTimestamp=@1704359521
+ExecMainStartTimestamp=1704359521000000
+DropInPaths=/etc/systemd/system/nginx.service.d/override.conf /run/systemd/system/nginx.service.d/50-x.conf
+MemoryCurrent=5242880
+CPUUsageNSec=[not set]
+TasksCurrent=18446744073709551615
+Wants=network-online.target
+After=network.target
+ remote-fs.target
+Before=
+`)
+	got := DetailsFromProperties(props)
+	want := UnitDetails{
+		Name:                   "nginx.service",
+		Description:            "A high performance web server",
+		ActiveState:            "active",
+		MainPID:                812,
+		NRestarts:              2,
+		ActiveEnterTimestamp:   time.Unix(1704359521, 0),
+		ExecMainStartTimestamp: time.UnixMicro(1704359521000000),
+		DropInPaths:            []string{"/etc/systemd/system/nginx.service.d/override.conf", "/run/systemd/system/nginx.service.d/50-x.conf"},
+		MemoryCurrent:          5242880,
+		Triggers:               []string{},
+		TriggeredBy:            []string{},
+		Wants:                  []string{"network-online.target"},
+		Requires:               []string{},
+		After:                  []string{"network.target", "remote-fs.target"},
+		Before:                 []string{},
+		Properties:             props,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+	if up := got.Uptime(time.Unix(1704359581, 0)); up != time.Minute {
+		t.Errorf("uptime %s, want 1m", up)
+	}
+}
+
+// TestUnitDetailsFixture reads the details of a failed oneshot from
+// recorded 'systemctl show' output.
+func TestUnitDetailsFixture(t *testing.T) {
+	ex := NewFakeExecutor()
+	fixture := filepath.Join("..", "tui", "testdata", "show-fwupd-refresh.txt")
+	if err := ex.OnFixture(fixture, "systemctl", "--user", "show", "--no-pager", "fwupd-refresh.service"); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewSystemctlBackend(ex, Options{Scope: ScopeUser}).UnitDetails(context.Background(), "fwupd-refresh.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exited := time.Date(2024, 1, 4, 9, 12, 3, 0, time.UTC)
+	checks := []struct {
+		field     string
+		got, want interface{}
+	}{
+		{"Name", d.Name, "fwupd-refresh.service"},
+		{"Description", d.Description, "Refresh fwupd metadata and update motd"},
+		{"ActiveState", d.ActiveState, "failed"},
+		{"UnitFileState", d.UnitFileState, "static"},
+		{"Result", d.Result, "exit-code"},
+		{"ExecMainCode", d.ExecMainCode, 1},
+		{"ExecMainStatus", d.ExecMainStatus, 1},
+		{"MainPID", d.MainPID, 0},
+		{"FragmentPath", d.FragmentPath, "/usr/lib/systemd/system/fwupd-refresh.service"},
+		{"ExecMainStartTimestamp", d.ExecMainStartTimestamp.UTC(), time.Date(2024, 1, 4, 9, 12, 1, 0, time.UTC)},
+		{"ExecMainExitTimestamp", d.ExecMainExitTimestamp.UTC(), exited},
+		{"InactiveEnterTimestamp", d.InactiveEnterTimestamp.UTC(), exited},
+		{"Uptime", d.Uptime(exited), time.Duration(0)},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.field, c.got, c.want)
+		}
+	}
+	if len(d.Properties) != 20 {
+		t.Errorf("%d properties, want all 20 lines", len(d.Properties))
+	}
+}
@@ -0,0 +1,170 @@
+// package system
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// Executor runs external commands on behalf of the system package.
+// The TUI never calls os/exec directly; it goes through an Executor so that
+// tests can swap in a FakeExecutor that replays canned systemctl output.
+type Executor interface {
+	// Run executes the command and waits for it to finish.
+	Run(name string, args ...string) (Result, error)
+	// RunContext is like Run but kills the command when ctx is done.
+	RunContext(ctx context.Context, name string, args ...string) (Result, error)
+	// Stream starts the command and delivers its output line by line.
+	Stream(ctx context.Context, name string, args ...string) (Stream, error)
+}
+
+// Result holds everything a finished command produced.
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// ExitError is returned when a command ran but exited with a non-zero status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Line is a single line of output from a streaming command.
+type Line struct {
+	Text   string
+	Stderr bool // true if the line was written to stderr
+}
+
+// Stream is a running command started by Executor.Stream.
+type Stream interface {
+	// Lines is closed once both stdout and stderr have been drained.
+	Lines() <-chan Line
+	// Wait blocks until the command exits. It returns nil, an *ExitError,
+	// or the error that prevented the command from running.
+	// Lines must be drained before calling Wait.
+	Wait() error
+}
+
+// doneStream is a Stream over output that has already been produced, for
+// backends that can only report a command's result once it has finished.
+type doneStream struct {
+	lines chan Line
+	err   error
+}
+
+func newDoneStream(output string, err error) *doneStream {
+	s := &doneStream{lines: make(chan Line), err: err}
+	go func() {
+		defer close(s.lines)
+		for _, l := range splitLines(output, false) {
+			s.lines <- l
+		}
+	}()
+	return s
+}
+
+func (s *doneStream) Lines() <-chan Line { return s.lines }
+
+func (s *doneStream) Wait() error { return s.err }
+
+// ExecExecutor is the real Executor, backed by os/exec.
+type ExecExecutor struct{}
+
+// NewExecExecutor returns an Executor that spawns real processes.
+func NewExecExecutor() *ExecExecutor {
+	return &ExecExecutor{}
+}
+
+// Run implements Executor.
+func (e *ExecExecutor) Run(name string, args ...string) (Result, error) {
+	return e.RunContext(context.Background(), name, args...)
+}
+
+// RunContext implements Executor.
+func (e *ExecExecutor) RunContext(ctx context.Context, name string, args ...string) (Result, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	killGroupOnCancel(cmd)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	res := Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	return res, wrapExitError(&res, err)
+}
+
+// Stream implements Executor.
+func (e *ExecExecutor) Stream(ctx context.Context, name string, args ...string) (Stream, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	killGroupOnCancel(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &execStream{cmd: cmd, lines: make(chan Line)}
+	s.wg.Add(2)
+	go s.pump(stdout, false)
+	go s.pump(stderr, true)
+	go func() {
+		s.wg.Wait()
+		close(s.lines)
+	}()
+	return s, nil
+}
+
+// execStream adapts a started exec.Cmd to the Stream interface.
+type execStream struct {
+	cmd   *exec.Cmd
+	lines chan Line
+	wg    sync.WaitGroup
+}
+
+func (s *execStream) pump(r io.Reader, isStderr bool) {
+	defer s.wg.Done()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // journal lines can be long
+	for scanner.Scan() {
+		s.lines <- Line{Text: scanner.Text(), Stderr: isStderr}
+	}
+}
+
+func (s *execStream) Lines() <-chan Line { return s.lines }
+
+func (s *execStream) Wait() error {
+	// Pipes must be drained before cmd.Wait closes them.
+	s.wg.Wait()
+	var res Result
+	return wrapExitError(&res, s.cmd.Wait())
+}
+
+// wrapExitError turns an *exec.ExitError into our own *ExitError and records
+// the exit code in res, so callers never depend on os/exec types.
+func wrapExitError(res *Result, err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.ExitCode = exitErr.ExitCode()
+		return &ExitError{Code: res.ExitCode}
+	}
+	return err
+}
@@ -0,0 +1,250 @@
+// package system
+package system
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FailureReport gathers what is known about why a unit failed.
+type FailureReport struct {
+	Details UnitDetails
+
+	// The newest error-priority journal entries of the unit, oldest first
+	Errors    []JournalEntry
+	ErrorsErr error // the journal could not be read
+
+	// Core dumps of the unit's processes, newest first
+	Coredumps    []Coredump
+	CoredumpsErr error // coredumpctl is missing or failed
+}
+
+// Triage builds the FailureReport of unit: its properties from backend,
+// and its last errorLines error entries and its core dumps through ex
+// against the manager selected by opts. Only a failure to read the
+// properties is an error; the journal and coredumpctl are best effort.
+func Triage(ctx context.Context, backend Backend, ex Executor, opts Options, unit string, errorLines int) (FailureReport, error) {
+	details, err := backend.UnitDetails(ctx, unit)
+	if err != nil {
+		return FailureReport{}, err
+	}
+	r := FailureReport{Details: details}
+	r.Errors, r.ErrorsErr = ReadJournal(ctx, ex, opts, ErrorQuery(unit, errorLines))
+	r.Coredumps, r.CoredumpsErr = ListCoredumps(ctx, ex, opts, unit)
+	return r, nil
+}
+
+// ErrorQuery selects the newest n entries of unit at error priority or
+// more urgent.
+func ErrorQuery(unit string, n int) JournalQuery {
+	return JournalQuery{Unit: unit, Limit: n, Priority: "err"}
+}
+
+// Exit codes of SIGCHLD (siginfo si_code), as in ExecMainCode.
+const (
+	CLDExited = 1
+	CLDKilled = 2
+	CLDDumped = 3
+)
+
+// DescribeExit explains a process exit given as ExecMainCode and
+// ExecMainStatus, e.g. "exited, status=203/EXEC (the executable could not
+// be run)" or "killed, signal=SIGKILL". It is "" if the process never
+// exited.
+func DescribeExit(code, status int) string {
+	switch code {
+	case CLDExited:
+		s := "exited, status=" + strconv.Itoa(status)
+		if e, ok := exitStatuses[status]; ok {
+			if e.name != "" {
+				s += "/" + e.name
+			}
+			s += " (" + e.meaning + ")"
+		} else if status > 128 && status < 128+len(signalNames) {
+			// A shell reports a child killed by a signal as 128+n
+			s += " (a child process was killed by " + SignalName(status-128) + ")"
+		}
+		return s
+	case CLDKilled:
+		return "killed, signal=" + SignalName(status)
+	case CLDDumped:
+		return "dumped core, signal=" + SignalName(status)
+	case 0:
+		return ""
+	default:
+		return fmt.Sprintf("code=%d, status=%d", code, status)
+	}
+}
+
+// DescribeResult explains a unit's Result property, e.g. "exit-code".
+func DescribeResult(result string) string {
+	if meaning, ok := resultMeanings[result]; ok {
+		return result + " (" + meaning + ")"
+	}
+	return result
+}
+
+var resultMeanings = map[string]string{
+	"success":           "no failure",
+	"exit-code":         "the main process exited with a non-zero status",
+	"signal":            "the main process was killed by a signal",
+	"core-dump":         "the main process crashed and dumped core",
+	"timeout":           "an operation timed out",
+	"watchdog":          "the watchdog was not pinged in time",
+	"start-limit-hit":   "started too often in a short time; see StartLimitBurst=",
+	"resources":         "systemd could not set up the unit, e.g. a missing PID file",
+	"protocol":          "the service did not follow the notification protocol",
+	"oom-kill":          "processes were killed by the out-of-memory killer",
+	"exec-condition":    "ExecCondition= failed",
+	"condition":         "a Condition*= check failed",
+	"dependency":        "a unit it depends on failed",
+	"trigger-limit-hit": "triggered too often in a short time",
+}
+
+// SignalName returns the Linux name of signal number n, e.g. "SIGKILL".
+func SignalName(n int) string {
+	if n > 0 && n < len(signalNames) {
+		return signalNames[n]
+	}
+	return "signal " + strconv.Itoa(n)
+}
+
+// signalNames are indexed by Linux signal number. Remote hosts are Linux
+// too, so the local platform's numbering is not used.
+var signalNames = []string{"",
+	"SIGHUP", "SIGINT", "SIGQUIT", "SIGILL", "SIGTRAP", "SIGABRT", "SIGBUS", "SIGFPE",
+	"SIGKILL", "SIGUSR1", "SIGSEGV", "SIGUSR2", "SIGPIPE", "SIGALRM", "SIGTERM", "SIGSTKFLT",
+	"SIGCHLD", "SIGCONT", "SIGSTOP", "SIGTSTP", "SIGTTIN", "SIGTTOU", "SIGURG", "SIGXCPU",
+	"SIGXFSZ", "SIGVTALRM", "SIGPROF", "SIGWINCH", "SIGIO", "SIGPWR", "SIGSYS",
+}
+
+// exitStatuses are the LSB and systemd exit statuses, see systemd.exec(5).
+var exitStatuses = map[int]struct{ name, meaning string }{
+	0:   {"SUCCESS", "success"},
+	1:   {"FAILURE", "generic failure"},
+	2:   {"INVALIDARGUMENT", "invalid or excess arguments"},
+	3:   {"NOTIMPLEMENTED", "unimplemented feature"},
+	4:   {"NOPERMISSION", "insufficient privileges"},
+	5:   {"NOTINSTALLED", "program is not installed"},
+	6:   {"NOTCONFIGURED", "program is not configured"},
+	7:   {"NOTRUNNING", "program is not running"},
+	126: {"", "command found but not executable"},
+	127: {"", "command not found"},
+	200: {"CHDIR", "could not change to WorkingDirectory="},
+	201: {"NICE", "could not set the nice level"},
+	202: {"FDS", "could not close unwanted file descriptors"},
+	203: {"EXEC", "the executable could not be run"},
+	204: {"MEMORY", "out of memory"},
+	205: {"LIMITS", "could not set resource limits"},
+	206: {"OOM_ADJUST", "could not adjust the OOM score"},
+	207: {"SIGNAL_MASK", "could not set the signal mask"},
+	208: {"STDIN", "could not set up standard input"},
+	209: {"STDOUT", "could not set up standard output"},
+	210: {"CHROOT", "could not change the root directory"},
+	211: {"IOPRIO", "could not set the I/O priority"},
+	212: {"TIMERSLACK", "could not set the timer slack"},
+	213: {"SECUREBITS", "could not set the secure bits"},
+	214: {"SETSCHEDULER", "could not set the scheduling parameters"},
+	215: {"CPUAFFINITY", "could not set the CPU affinity"},
+	216: {"GROUP", "could not set up the group; does Group= exist?"},
+	217: {"USER", "could not set up the user; does User= exist?"},
+	218: {"CAPABILITIES", "could not drop capabilities"},
+	219: {"CGROUP", "could not set up the cgroup"},
+	220: {"SETSID", "could not create a new session"},
+	221: {"CONFIRM", "execution cancelled at the confirmation prompt"},
+	222: {"STDERR", "could not set up standard error"},
+	224: {"PAM", "the PAM session failed"},
+	225: {"NETWORK", "could not set up network namespacing"},
+	226: {"NAMESPACE", "could not set up mount namespacing"},
+	227: {"NO_NEW_PRIVILEGES", "could not disable new privileges"},
+	228: {"SECCOMP", "could not apply the system call filter"},
+	229: {"SELINUX_CONTEXT", "could not set the SELinux context"},
+	230: {"PERSONALITY", "could not set the execution domain"},
+	231: {"APPARMOR_PROFILE", "could not set the AppArmor profile"},
+	232: {"ADDRESS_FAMILIES", "could not restrict address families"},
+	233: {"RUNTIME_DIRECTORY", "could not create RuntimeDirectory="},
+	235: {"CHOWN", "could not change socket ownership"},
+	236: {"SMACK_PROCESS_LABEL", "could not set the SMACK label"},
+	237: {"KEYRING", "could not set up the kernel keyring"},
+	238: {"STATE_DIRECTORY", "could not create StateDirectory="},
+	239: {"CACHE_DIRECTORY", "could not create CacheDirectory="},
+	240: {"LOGS_DIRECTORY", "could not create LogsDirectory="},
+	241: {"CONFIGURATION_DIRECTORY", "could not create ConfigurationDirectory="},
+	242: {"NUMA_POLICY", "could not set the NUMA policy"},
+	243: {"CREDENTIALS", "could not set up credentials"},
+	245: {"BPF", "could not apply BPF restrictions"},
+}
+
+// Coredump is one core dump recorded by systemd-coredump.
+type Coredump struct {
+	Time     string // as coredumpctl prints it
+	PID      int
+	Signal   string
+	Corefile string // present, missing, inaccessible, ... ("" on old versions)
+	Exe      string
+}
+
+// ListCoredumps returns the core dumps of unit's processes, newest first.
+// coredumpctl has no -H or -M, so only local units can be looked up.
+func ListCoredumps(ctx context.Context, ex Executor, opts Options, unit string) ([]Coredump, error) {
+	if !opts.Target.IsLocal() {
+		return nil, fmt.Errorf("coredumpctl cannot read the core dumps of %s", opts.Target)
+	}
+	match := "COREDUMP_UNIT=" + unit
+	if opts.Scope == ScopeUser {
+		match = "COREDUMP_USER_UNIT=" + unit
+	}
+	argv := []string{"list", "--no-pager", "--no-legend", "-r", match}
+	res, err := ex.RunContext(ctx, "coredumpctl", argv...)
+	var exit *ExitError
+	if errors.As(err, &exit) && strings.Contains(string(res.Stderr), "No coredumps found") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("coredumpctl list: %w", err)
+	}
+	return ParseCoredumpList(string(res.Stdout)), nil
+}
+
+// ParseCoredumpList parses 'coredumpctl list --no-legend' output. Each line
+// starts with a four-field timestamp, then PID, UID, GID, the signal (a name
+// or, on old versions, a number), the core file state and the executable:
+//
+//	Thu 2024-01-04 09:12:03 UTC 1234 0 0 SIGSEGV present /usr/bin/foo 1.2M
+func ParseCoredumpList(out string) []Coredump {
+	var dumps []Coredump
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 9 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[4])
+		if err != nil {
+			continue
+		}
+		d := Coredump{Time: strings.Join(fields[:4], " "), PID: pid, Signal: fields[7]}
+		if n, err := strconv.Atoi(d.Signal); err == nil {
+			d.Signal = SignalName(n)
+		}
+		rest := fields[8:]
+		if !strings.HasPrefix(rest[0], "/") && len(rest) > 1 {
+			d.Corefile, rest = rest[0], rest[1:]
+		}
+		d.Exe = rest[0]
+		dumps = append(dumps, d)
+	}
+	return dumps
+}
+
+// FailedSince is when unit last entered a failed or inactive state, falling
+// back to its last state change.
+func (d UnitDetails) FailedSince() time.Time {
+	if !d.InactiveEnterTimestamp.IsZero() {
+		return d.InactiveEnterTimestamp
+	}
+	return d.StateChangeTimestamp
+}
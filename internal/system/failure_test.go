@@ -0,0 +1,155 @@
+package system
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescribeExit(t *testing.T) {
+	tests := []struct {
+		name         string
+		code, status int
+		want         string
+	}{
+		{"never exited", 0, 0, ""},
+		{"success", CLDExited, 0, "exited, status=0/SUCCESS (success)"},
+		{"LSB status", CLDExited, 3, "exited, status=3/NOTIMPLEMENTED (unimplemented feature)"},
+		{"systemd status", CLDExited, 203, "exited, status=203/EXEC (the executable could not be run)"},
+		{"status without a name", CLDExited, 127, "exited, status=127 (command not found)"},
+		{"unknown status", CLDExited, 42, "exited, status=42"},
+		{"child killed", CLDExited, 137, "exited, status=137 (a child process was killed by SIGKILL)"},
+		{"child killed by the last signal", CLDExited, 128 + 31, "exited, status=159 (a child process was killed by SIGSYS)"},
+		{"128 is no signal", CLDExited, 128, "exited, status=128"},
+		{"past the signals", CLDExited, 128 + 32, "exited, status=160"},
+		{"killed", CLDKilled, 9, "killed, signal=SIGKILL"},
+		{"killed by a realtime signal", CLDKilled, 34, "killed, signal=signal 34"},
+		{"dumped", CLDDumped, 11, "dumped core, signal=SIGSEGV"},
+		{"other code", 5, 1, "code=5, status=1"},
+	}
+	for _, tt := range tests {
+		if got := DescribeExit(tt.code, tt.status); got != tt.want {
+			t.Errorf("%s: DescribeExit(%d, %d) = %q, want %q", tt.name, tt.code, tt.status, got, tt.want)
+		}
+	}
+}
+
+// TestExitStatuses checks the table against systemd.exec(5): names as
+// systemd prints them, and a meaning for every status.
+func TestExitStatuses(t *testing.T) {
+	for status, e := range exitStatuses {
+		if e.meaning == "" {
+			t.Errorf("status %d has no meaning", status)
+		}
+		if e.name != strings.ToUpper(e.name) || strings.ContainsAny(e.name, " -") {
+			t.Errorf("status %d is named %q", status, e.name)
+		}
+		if status > 128 && status < 128+len(signalNames) {
+			t.Errorf("status %d shadows the signal heuristic", status)
+		}
+	}
+	for status, name := range map[int]string{1: "FAILURE", 200: "CHDIR", 217: "USER", 226: "NAMESPACE", 245: "BPF"} {
+		if got := exitStatuses[status].name; got != name {
+			t.Errorf("status %d is %q, want %q", status, got, name)
+		}
+	}
+}
+
+func TestDescribeResult(t *testing.T) {
+	tests := map[string]string{
+		"exit-code":       "exit-code (the main process exited with a non-zero status)",
+		"start-limit-hit": "start-limit-hit (started too often in a short time; see StartLimitBurst=)",
+		"new-result":      "new-result",
+		"":                "",
+	}
+	for result, want := range tests {
+		if got := DescribeResult(result); got != want {
+			t.Errorf("DescribeResult(%q) = %q, want %q", result, got, want)
+		}
+	}
+}
+
+func TestSignalName(t *testing.T) {
+	tests := map[int]string{
+		1:  "SIGHUP",
+		9:  "SIGKILL",
+		15: "SIGTERM",
+		31: "SIGSYS",
+		0:  "signal 0",
+		32: "signal 32",
+		-1: "signal -1",
+	}
+	for n, want := range tests {
+		if got := SignalName(n); got != want {
+			t.Errorf("SignalName(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestParseCoredumpList(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Coredump
+	}{
+		{
+			fixture: "coredumpctl-list-v255.txt",
+			want: []Coredump{
+				{Time: "Thu 2024-01-04 09:12:03 UTC", PID: 2231, Signal: "SIGSEGV", Corefile: "present", Exe: "/usr/lib/fwupd/fwupd"},
+				{Time: "Wed 2024-01-03 18:40:11 UTC", PID: 1987, Signal: "SIGABRT", Corefile: "missing", Exe: "/usr/lib/fwupd/fwupd"},
+				{Time: "Tue 2024-01-02 07:02:55 UTC", PID: 1410, Signal: "SIGSEGV", Corefile: "inaccessible", Exe: "/usr/lib/fwupd/fwupd"},
+			},
+		},
+		{
+			// Numeric signals, and no core file column for lost dumps
+			fixture: "coredumpctl-list-v245.txt",
+			want: []Coredump{
+				{Time: "Tue 2020-06-02 11:20:31 CEST", PID: 1342, Signal: "SIGSEGV", Corefile: "present", Exe: "/usr/sbin/named"},
+				{Time: "Mon 2020-06-01 08:02:10 CEST", PID: 997, Signal: "SIGABRT", Exe: "/usr/sbin/named"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			ex := NewFakeExecutor()
+			if err := ex.OnFixture(filepath.Join("testdata", tt.fixture), "coredumpctl", "list", "--no-pager", "--no-legend", "-r", "COREDUMP_UNIT=a.service"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ListCoredumps(context.Background(), ex, Options{}, "a.service")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := ParseCoredumpList("\nThu 2024-01-04 09:12:03 UTC\nThu 2024-01-04 09:12:03 UTC pid 0 0 SIGSEGV present /bin/x\n"); got != nil {
+		t.Errorf("garbled lines parsed as %+v", got)
+	}
+}
+
+func TestListCoredumps(t *testing.T) {
+	ex := NewFakeExecutor()
+	// coredumpctl exits 1 when nothing matches; that is no error
+	ex.On(FakeResponse{Stderr: "No coredumps found.\n", ExitCode: 1}, "coredumpctl", "list", "--no-pager", "--no-legend", "-r", "COREDUMP_USER_UNIT=a.service")
+	got, err := ListCoredumps(context.Background(), ex, Options{Scope: ScopeUser}, "a.service")
+	if got != nil || err != nil {
+		t.Errorf("no core dumps: got %+v, %v", got, err)
+	}
+
+	ex.On(FakeResponse{Stderr: "Failed to acquire bus\n", ExitCode: 1}, "coredumpctl", "list", "--no-pager", "--no-legend", "-r", "COREDUMP_UNIT=b.service")
+	if _, err := ListCoredumps(context.Background(), ex, Options{}, "b.service"); err == nil || !strings.Contains(err.Error(), "coredumpctl list") {
+		t.Errorf("failure: got %v", err)
+	}
+
+	calls := len(ex.Calls())
+	if _, err := ListCoredumps(context.Background(), ex, Options{Target: Target{Host: "web1"}}, "a.service"); err == nil {
+		t.Error("remote target: want an error")
+	}
+	if len(ex.Calls()) != calls {
+		t.Error("coredumpctl was run for a remote target")
+	}
+}
@@ -0,0 +1,181 @@
+// package system
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FakeResponse is a canned result replayed by FakeExecutor.
+type FakeResponse struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int   // non-zero makes the call return an *ExitError
+	Err      error // if set, returned instead of running (e.g. "not found")
+}
+
+// FakeExecutor is a scriptable Executor for tests. Responses are registered
+// per argument vector with On and replayed in order; the last response for a
+// given vector is repeated once the queue is exhausted. Every call is
+// recorded and can be inspected with Calls.
+type FakeExecutor struct {
+	mu        sync.Mutex
+	responses map[string][]FakeResponse
+	calls     [][]string
+
+	// Fallback is returned for commands with no registered response.
+	// If nil, such calls fail with an error naming the command.
+	Fallback *FakeResponse
+}
+
+// NewFakeExecutor returns an empty FakeExecutor.
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{responses: make(map[string][]FakeResponse)}
+}
+
+// On registers resp as the next response for the exact command line name args.
+// It returns the executor so registrations can be chained.
+func (f *FakeExecutor) On(resp FakeResponse, name string, args ...string) *FakeExecutor {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := fakeKey(name, args)
+	f.responses[key] = append(f.responses[key], resp)
+	return f
+}
+
+// OnFixture registers the contents of a recorded output file as the stdout
+// of a successful run of name args.
+func (f *FakeExecutor) OnFixture(path string, name string, args ...string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading fixture %s: %w", path, err)
+	}
+	f.On(FakeResponse{Stdout: string(data)}, name, args...)
+	return nil
+}
+
+// Calls returns the argument vectors of every command run so far,
+// including the command name as the first element.
+func (f *FakeExecutor) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([][]string, len(f.calls))
+	copy(out, f.calls)
+	return out
+}
+
+// Run implements Executor.
+func (f *FakeExecutor) Run(name string, args ...string) (Result, error) {
+	return f.RunContext(context.Background(), name, args...)
+}
+
+// RunContext implements Executor.
+func (f *FakeExecutor) RunContext(ctx context.Context, name string, args ...string) (Result, error) {
+	resp, err := f.next(name, args)
+	if err != nil {
+		return Result{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	res := Result{Stdout: []byte(resp.Stdout), Stderr: []byte(resp.Stderr), ExitCode: resp.ExitCode}
+	return res, resp.exitErr()
+}
+
+// Stream implements Executor. Stdout lines are delivered before stderr lines.
+func (f *FakeExecutor) Stream(ctx context.Context, name string, args ...string) (Stream, error) {
+	resp, err := f.next(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &fakeStream{lines: make(chan Line), done: make(chan struct{})}
+	go func() {
+		defer close(s.lines)
+		defer close(s.done)
+		for _, l := range splitLines(resp.Stdout, false) {
+			select {
+			case s.lines <- l:
+			case <-ctx.Done():
+				s.err = ctx.Err()
+				return
+			}
+		}
+		for _, l := range splitLines(resp.Stderr, true) {
+			select {
+			case s.lines <- l:
+			case <-ctx.Done():
+				s.err = ctx.Err()
+				return
+			}
+		}
+		s.err = resp.exitErr()
+	}()
+	return s, nil
+}
+
+// next records the call and pops the response registered for it.
+func (f *FakeExecutor) next(name string, args []string) (FakeResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, append([]string{name}, args...))
+
+	key := fakeKey(name, args)
+	queue := f.responses[key]
+	switch {
+	case len(queue) > 1:
+		f.responses[key] = queue[1:]
+		return queue[0], nil
+	case len(queue) == 1:
+		return queue[0], nil
+	case f.Fallback != nil:
+		return *f.Fallback, nil
+	}
+	return FakeResponse{}, fmt.Errorf("fake executor: no response registered for %q", append([]string{name}, args...))
+}
+
+func (r FakeResponse) exitErr() error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.ExitCode != 0 {
+		return &ExitError{Code: r.ExitCode}
+	}
+	return nil
+}
+
+type fakeStream struct {
+	lines chan Line
+	done  chan struct{}
+	err   error
+}
+
+func (s *fakeStream) Lines() <-chan Line { return s.lines }
+
+func (s *fakeStream) Wait() error {
+	<-s.done
+	return s.err
+}
+
+// fakeKey identifies an argument vector. NUL cannot occur in an argument,
+// so unlike spaces it never makes two different vectors collide.
+func fakeKey(name string, args []string) string {
+	return strings.Join(append([]string{name}, args...), "\x00")
+}
+
+func splitLines(text string, isStderr bool) []Line {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	parts := strings.Split(text, "\n")
+	lines := make([]Line, len(parts))
+	for i, p := range parts {
+		lines[i] = Line{Text: p, Stderr: isStderr}
+	}
+	return lines
+}
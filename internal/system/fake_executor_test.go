@@ -0,0 +1,89 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFakeExecutorKeys(t *testing.T) {
+	ex := NewFakeExecutor()
+	ex.On(FakeResponse{Stdout: "joined"}, "systemctl", "status", "a b.service")
+	ex.On(FakeResponse{Stdout: "split"}, "systemctl", "status", "a", "b.service")
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"status", "a b.service"}, "joined"},
+		{[]string{"status", "a", "b.service"}, "split"},
+	} {
+		res, err := ex.Run("systemctl", tt.args...)
+		if err != nil || string(res.Stdout) != tt.want {
+			t.Errorf("Run(%q) = %q, %v; want %q", tt.args, res.Stdout, err, tt.want)
+		}
+	}
+
+	_, err := ex.Run("systemctl", "status", "a", "b", ".service")
+	if err == nil || !strings.Contains(err.Error(), `["systemctl" "status" "a" "b" ".service"]`) {
+		t.Errorf("unregistered argv: got %v, want an error quoting each argument", err)
+	}
+}
+
+func TestFakeExecutorQueue(t *testing.T) {
+	ex := NewFakeExecutor()
+	ex.On(FakeResponse{Stdout: "activating\n", ExitCode: 3}, "systemctl", "is-active", "a.service").
+		On(FakeResponse{Stdout: "active\n"}, "systemctl", "is-active", "a.service")
+
+	var exit *ExitError
+	res, err := ex.Run("systemctl", "is-active", "a.service")
+	if !errors.As(err, &exit) || exit.Code != 3 || string(res.Stdout) != "activating\n" {
+		t.Errorf("first call: %q, %v", res.Stdout, err)
+	}
+	for i := 0; i < 2; i++ { // the last response repeats
+		res, err = ex.Run("systemctl", "is-active", "a.service")
+		if err != nil || string(res.Stdout) != "active\n" {
+			t.Errorf("call %d: %q, %v", i+2, res.Stdout, err)
+		}
+	}
+	if got := len(ex.Calls()); got != 3 {
+		t.Errorf("%d calls recorded, want 3", got)
+	}
+
+	ex.Fallback = &FakeResponse{Stderr: "nope\n", ExitCode: 1}
+	if res, err := ex.Run("journalctl"); !errors.As(err, &exit) || string(res.Stderr) != "nope\n" {
+		t.Errorf("fallback: %q, %v", res.Stderr, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ex.RunContext(ctx, "systemctl", "is-active", "a.service"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: got %v", err)
+	}
+}
+
+func TestFakeExecutorStream(t *testing.T) {
+	ex := NewFakeExecutor()
+	ex.On(FakeResponse{Stdout: "one\ntwo\n", Stderr: "warn\n", ExitCode: 1}, "systemctl", "restart", "--", "a.service")
+
+	s, err := ex.Stream(context.Background(), "systemctl", "restart", "--", "a.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for l := range s.Lines() {
+		if l.Stderr {
+			got = append(got, "E:"+l.Text)
+		} else {
+			got = append(got, l.Text)
+		}
+	}
+	if strings.Join(got, ",") != "one,two,E:warn" {
+		t.Errorf("lines %q", got)
+	}
+	var exit *ExitError
+	if err := s.Wait(); !errors.As(err, &exit) || exit.Code != 1 {
+		t.Errorf("Wait = %v, want exit status 1", err)
+	}
+}
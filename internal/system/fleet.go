@@ -0,0 +1,127 @@
+// package system
+package system
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HostResult is one target's contribution to a fleet fetch.
+type HostResult struct {
+	Target Target
+	Units  []Unit // each with Host set to Target.String()
+	Err    error
+}
+
+// FetchFleet lists units on every target concurrently, running at most
+// workers fetches at a time. Every other setting (scope, backend flags) is
+// taken from opts. Each target gets timeout to itself (none if zero), so a
+// hung host only fails its own fetch. Results are returned in the order of
+// targets; a failing target only sets its own Err.
+func FetchFleet(ctx context.Context, ex Executor, opts Options, targets []Target, workers int, timeout time.Duration) []HostResult {
+	results := make([]HostResult, len(targets))
+	forEachTarget(targets, workers, func(i int, target Target) {
+		results[i] = fetchHost(ctx, ex, opts, target, timeout)
+	})
+	return results
+}
+
+// StreamFleet is FetchFleet delivering each target's result as soon as it
+// is in, in whatever order the targets answer. The channel is closed after
+// the last one. It is buffered for every target, so the fetch finishes even
+// if nobody reads it.
+func StreamFleet(ctx context.Context, ex Executor, opts Options, targets []Target, workers int, timeout time.Duration) <-chan HostResult {
+	results := make(chan HostResult, len(targets))
+	go func() {
+		defer close(results)
+		forEachTarget(targets, workers, func(_ int, target Target) {
+			results <- fetchHost(ctx, ex, opts, target, timeout)
+		})
+	}()
+	return results
+}
+
+// FetchStates asks every target for its manager state (see SystemState)
+// the way FetchFleet lists their units: concurrently, and each under its
+// own timeout. A target that cannot tell is "unknown". States are returned
+// in the order of targets.
+func FetchStates(ctx context.Context, ex Executor, opts Options, targets []Target, workers int, timeout time.Duration) []string {
+	states := make([]string, len(targets))
+	forEachTarget(targets, workers, func(i int, target Target) {
+		ctx, cancel := hostContext(ctx, timeout)
+		defer cancel()
+		opts := opts
+		opts.Target = target
+		state, err := SystemState(ctx, ex, opts)
+		if err != nil {
+			state = "unknown"
+		}
+		states[i] = state
+	})
+	return states
+}
+
+// forEachTarget calls do for every target on a pool of workers, passing
+// its index in targets, and returns once all calls have.
+func forEachTarget(targets []Target, workers int, do func(int, Target)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				do(i, targets[i])
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// hostContext bounds the work on one target by timeout, if there is one.
+// It only starts once a worker picks the target up, not while the target
+// waits behind slower ones.
+func hostContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// fetchHost lists the units of one target.
+func fetchHost(ctx context.Context, ex Executor, opts Options, target Target, timeout time.Duration) HostResult {
+	ctx, cancel := hostContext(ctx, timeout)
+	defer cancel()
+	opts.Target = target
+	units, err := NewSystemctlBackend(ex, opts).ListUnits(ctx)
+	for i := range units {
+		units[i].Host = target.String()
+	}
+	return HostResult{Target: target, Units: units, Err: err}
+}
+
+// MergeFleet combines the units of all results into one list sorted by unit
+// name and then host, so the same unit on different hosts sits together.
+func MergeFleet(results []HostResult) []Unit {
+	var units []Unit
+	for _, r := range results {
+		units = append(units, r.Units...)
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].Name != units[j].Name {
+			return units[i].Name < units[j].Name
+		}
+		return units[i].Host < units[j].Host
+	})
+	return units
+}
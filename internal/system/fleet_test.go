@@ -0,0 +1,152 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hangingExecutor is a FakeExecutor whose commands for the hosts in hang
+// never finish before their context is done, like an unreachable SSH target.
+type hangingExecutor struct {
+	*FakeExecutor
+	hang map[string]bool
+}
+
+func (h hangingExecutor) RunContext(ctx context.Context, name string, args ...string) (Result, error) {
+	if len(args) > 1 && args[0] == "-H" && h.hang[args[1]] {
+		<-ctx.Done()
+		return Result{}, ctx.Err()
+	}
+	return h.FakeExecutor.RunContext(ctx, name, args...)
+}
+
+// onListUnits registers the unit table the manager opts selects prints.
+func onListUnits(ex *FakeExecutor, opts Options, resp FakeResponse) {
+	ex.On(resp, "systemctl", append(opts.Flags(), listUnitsArgs...)...)
+}
+
+func TestFetchFleet(t *testing.T) {
+	local := Target{}
+	web1 := Target{Host: "root@web1"}
+	box := Target{Machine: "box"}
+	down := Target{Host: "down"}
+
+	ex := NewFakeExecutor()
+	onListUnits(ex, Options{Scope: ScopeUser, Target: local}, FakeResponse{Stdout: "sshd.service loaded active running OpenSSH\ncron.service loaded active running Cron\n"})
+	onListUnits(ex, Options{Scope: ScopeUser, Target: web1}, FakeResponse{Stdout: "nginx.service loaded failed failed Web server\nsshd.service loaded active running OpenSSH\n"})
+	onListUnits(ex, Options{Scope: ScopeUser, Target: box}, FakeResponse{Stdout: "sshd.service loaded inactive dead OpenSSH\n"})
+	onListUnits(ex, Options{Scope: ScopeUser, Target: down}, FakeResponse{Stderr: "ssh: connect to host down port 22: No route to host\n", ExitCode: 255})
+
+	targets := []Target{local, web1, box, down}
+	results := FetchFleet(context.Background(), ex, Options{Scope: ScopeUser}, targets, 2, time.Second)
+
+	// One list-units per target, through its own transport flags
+	var calls []string
+	for _, c := range ex.Calls() {
+		calls = append(calls, strings.Join(c, " "))
+	}
+	slices.Sort(calls)
+	want := []string{
+		"systemctl --user -H down list-units --all --no-legend --plain --output=json",
+		"systemctl --user -H root@web1 list-units --all --no-legend --plain --output=json",
+		"systemctl --user -M box list-units --all --no-legend --plain --output=json",
+		"systemctl --user list-units --all --no-legend --plain --output=json",
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("ran\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+
+	for i, r := range results {
+		if r.Target != targets[i] {
+			t.Errorf("result %d is for %s, want %s", i, r.Target, targets[i])
+		}
+		if failed := r.Err != nil; failed != (r.Target == down) {
+			t.Errorf("%s: error %v", r.Target, r.Err)
+		}
+		for _, u := range r.Units {
+			if u.Host != r.Target.String() {
+				t.Errorf("%s: unit %s has host %q", r.Target, u.Name, u.Host)
+			}
+		}
+	}
+	var exitErr *ExitError
+	if err := results[3].Err; !errors.As(err, &exitErr) || exitErr.Code != 255 {
+		t.Errorf("unreachable host: got %v, want exit status 255", err)
+	}
+
+	// The failing host leaves the others' units merged, by name then host
+	var keys []string
+	for _, u := range MergeFleet(results) {
+		keys = append(keys, u.Key())
+	}
+	wantKeys := []string{"local/cron.service", "root@web1/nginx.service", "local/sshd.service", "machine:box/sshd.service", "root@web1/sshd.service"}
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("merged %q\nwant %q", keys, wantKeys)
+	}
+}
+
+// TestFetchFleetTimeoutPerHost checks that a hung host only times out
+// itself: the host queued behind it still gets its whole timeout.
+func TestFetchFleetTimeoutPerHost(t *testing.T) {
+	ex := hangingExecutor{FakeExecutor: NewFakeExecutor(), hang: map[string]bool{"hung": true}}
+	onListUnits(ex.FakeExecutor, Options{Target: Target{Host: "web1"}}, FakeResponse{Stdout: "sshd.service loaded active running OpenSSH\n"})
+
+	// One worker: web1 only starts once hung has used up its timeout
+	targets := []Target{{Host: "hung"}, {Host: "web1"}}
+	results := FetchFleet(context.Background(), ex, Options{}, targets, 1, 50*time.Millisecond)
+	if !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("hung host: got %v, want the deadline", results[0].Err)
+	}
+	if results[1].Err != nil || len(results[1].Units) != 1 {
+		t.Errorf("host after the hung one: %+v", results[1])
+	}
+}
+
+// TestStreamFleet checks that results are delivered as each host answers,
+// not once all of them have.
+func TestStreamFleet(t *testing.T) {
+	ex := hangingExecutor{FakeExecutor: NewFakeExecutor(), hang: map[string]bool{"hung": true}}
+	onListUnits(ex.FakeExecutor, Options{Target: Target{Host: "web1"}}, FakeResponse{Stdout: "sshd.service loaded active running OpenSSH\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := StreamFleet(ctx, ex, Options{}, []Target{{Host: "hung"}, {Host: "web1"}}, 2, 0)
+
+	select {
+	case r := <-results:
+		if r.Target.Host != "web1" || r.Err != nil {
+			t.Fatalf("first result %+v, want web1's units", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("web1's result waited for the hung host")
+	}
+
+	cancel()
+	r, ok := <-results
+	if !ok || !errors.Is(r.Err, context.Canceled) {
+		t.Errorf("hung host: got %+v, %v", r, ok)
+	}
+	if _, ok := <-results; ok {
+		t.Error("channel not closed after the last host")
+	}
+}
+
+// TestFetchStates checks that a hung host leaves the states of the hosts
+// queued behind it alone.
+func TestFetchStates(t *testing.T) {
+	ex := hangingExecutor{FakeExecutor: NewFakeExecutor(), hang: map[string]bool{"hung": true}}
+	ex.On(FakeResponse{Stdout: "running\n"}, "systemctl", "is-system-running")
+	ex.On(FakeResponse{Stdout: "degraded\n", ExitCode: 1}, "systemctl", "-H", "web1", "is-system-running")
+	ex.On(FakeResponse{Stderr: "ssh: Could not resolve hostname gone\n", ExitCode: 255}, "systemctl", "-H", "gone", "is-system-running")
+
+	targets := []Target{{}, {Host: "hung"}, {Host: "web1"}, {Host: "gone"}}
+	got := FetchStates(context.Background(), ex, Options{}, targets, 1, 50*time.Millisecond)
+	want := []string{"running", "unknown", "degraded", "unknown"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+// package system
+package system
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ManagerJob is a job in the service manager's own queue, as listed by
+// 'systemctl list-jobs' (not to be confused with the TUI's job queue).
+type ManagerJob struct {
+	ID    uint32
+	Unit  string
+	Type  string // start, stop, restart, ...
+	State string // waiting or running
+}
+
+// ListJobs implements Backend.
+func (b *SystemctlBackend) ListJobs(ctx context.Context) ([]ManagerJob, error) {
+	argv := append(append([]string{}, b.flags...), "list-jobs", "--no-legend", "--no-pager")
+	res, err := b.exec.RunContext(ctx, "systemctl", argv...)
+	if err != nil {
+		return nil, fmt.Errorf("systemctl list-jobs: %w", err)
+	}
+	return parseJobsText(string(res.Stdout)), nil
+}
+
+// parseJobsText parses 'systemctl list-jobs --no-legend' rows
+// ("JOB UNIT TYPE STATE"). Anything else, such as "No jobs running.", is skipped.
+func parseJobsText(text string) []ManagerJob {
+	var jobs []ManagerJob
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, ManagerJob{ID: uint32(id), Unit: fields[1], Type: fields[2], State: fields[3]})
+	}
+	return jobs
+}
+
+// ListJobs implements Backend.
+func (b *DBusBackend) ListJobs(ctx context.Context) ([]ManagerJob, error) {
+	var raw []struct {
+		ID       uint32
+		Unit     string
+		Type     string
+		State    string
+		JobPath  dbus.ObjectPath
+		UnitPath dbus.ObjectPath
+	}
+	if err := b.call(ctx, "ListJobs").Store(&raw); err != nil {
+		return nil, managerError(err)
+	}
+
+	jobs := make([]ManagerJob, 0, len(raw))
+	for _, j := range raw {
+		jobs = append(jobs, ManagerJob{ID: j.ID, Unit: j.Unit, Type: j.Type, State: j.State})
+	}
+	return jobs, nil
+}
@@ -0,0 +1,236 @@
+// package system
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecentLogs returns the last n journal lines of unit, oldest first, from
+// the manager selected by opts (journalctl understands the same --user,
+// -H and -M flags as systemctl).
+func RecentLogs(ctx context.Context, ex Executor, opts Options, unit string, n int) ([]string, error) {
+	argv := append(append([]string{}, opts.Flags()...), "-u", unit, "-n", strconv.Itoa(n), "--no-pager", "-q", "-o", "short")
+	res, err := ex.RunContext(ctx, "journalctl", argv...)
+	if err != nil {
+		return nil, fmt.Errorf("journalctl -u %s: %w", unit, err)
+	}
+	out := strings.TrimRight(string(res.Stdout), "\n")
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// JournalEntry is one journal record, as printed by 'journalctl -o json'.
+type JournalEntry struct {
+	Time       time.Time
+	Priority   int // 0 (emerg) to 7 (debug), as in syslog
+	PID        int
+	Identifier string // SYSLOG_IDENTIFIER, or the process name
+	Message    string
+	Cursor     string // where to resume reading; see JournalQuery
+}
+
+// DefaultJournalPriority is assumed for entries without a PRIORITY field.
+const DefaultJournalPriority = 6 // info
+
+// JournalQuery selects the journal entries of one unit.
+type JournalQuery struct {
+	Unit  string
+	Limit int // at most this many entries, the newest ones; zero means all
+	// Before, if set, is a cursor: only entries older than it are read,
+	// for paging back through history.
+	Before string
+	// Follow keeps reading new entries as they are written (journalctl -f);
+	// see FollowJournal. With After set it starts just after that cursor,
+	// otherwise with the newest Limit entries (none if Limit is zero).
+	Follow bool
+	After  string
+
+	// Filters; empty ones are left out
+	Priority string // show this priority and more urgent ones, e.g. "err"
+	Since    string // journalctl time, e.g. "-1h", "today", "2024-01-02 10:00"
+	Until    string
+	Boot     string // boot ID or offset (0 is the current boot, -1 the one before)
+	Grep     string // regular expression the message must match
+}
+
+// JournalPriorities are the syslog priority names, most urgent first, so a
+// name's index is its number.
+var JournalPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// Args are the journalctl arguments for q against the manager selected by
+// opts (journalctl understands the same --user, -H and -M flags as systemctl).
+func (q JournalQuery) Args(opts Options) []string {
+	args := append([]string{}, opts.Flags()...)
+	args = append(args, "-u", q.Unit, "-o", "json", "--no-pager", "-q")
+	switch {
+	case q.Limit > 0:
+		args = append(args, "-n", strconv.Itoa(q.Limit))
+	case q.Follow && q.After == "":
+		args = append(args, "-n", "0") // only what is written from now on
+	}
+	if q.Before != "" {
+		// Walk backwards from just before the cursor
+		args = append(args, "--reverse", "--after-cursor="+q.Before)
+	}
+	if q.After != "" {
+		args = append(args, "--after-cursor="+q.After)
+	}
+	if q.Follow {
+		args = append(args, "-f")
+	}
+	if q.Priority != "" {
+		args = append(args, "-p", q.Priority)
+	}
+	if q.Since != "" {
+		args = append(args, "--since="+q.Since)
+	}
+	if q.Until != "" {
+		args = append(args, "--until="+q.Until)
+	}
+	if q.Boot != "" {
+		args = append(args, "-b", q.Boot)
+	}
+	if q.Grep != "" {
+		args = append(args, "-g", q.Grep)
+	}
+	return args
+}
+
+// Boot is one boot recorded in the journal, from 'journalctl --list-boots'.
+type Boot struct {
+	Offset int // 0 is the current boot, -1 the one before, ...
+	ID     string
+	Span   string // first and last entry, as journalctl prints them
+}
+
+// ListBoots returns the boots in the journal of the manager selected by
+// opts, oldest first.
+func ListBoots(ctx context.Context, ex Executor, opts Options) ([]Boot, error) {
+	argv := append(append([]string{}, opts.Flags()...), "--list-boots", "--no-pager", "-q")
+	res, err := ex.RunContext(ctx, "journalctl", argv...)
+	if err != nil {
+		return nil, fmt.Errorf("journalctl --list-boots: %w", err)
+	}
+	return ParseBootList(string(res.Stdout)), nil
+}
+
+// ParseBootList parses 'journalctl --list-boots' output:
+//
+//	IDX BOOT ID                          FIRST ENTRY                 LAST ENTRY
+//	 -1 5b3c0d7e8a9f4e1d9c2b7a6f5e4d3c2b Thu 2024-01-04 09:12:03 UTC Thu 2024-01-04 18:40:11 UTC
+//	  0 0f1e2d3c4b5a69788796a5b4c3d2e1f0 Fri 2024-01-05 08:01:44 UTC Fri 2024-01-05 11:20:09 UTC
+//
+// The header, which older versions don't print, is skipped.
+func ParseBootList(out string) []Boot {
+	var boots []Boot
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		offset, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		boots = append(boots, Boot{Offset: offset, ID: fields[1], Span: strings.Join(fields[2:], " ")})
+	}
+	return boots
+}
+
+// FollowJournal starts 'journalctl -f' for q; each line of the stream is
+// one entry for ParseJournalJSON. The process is killed when ctx is done.
+func FollowJournal(ctx context.Context, ex Executor, opts Options, q JournalQuery) (Stream, error) {
+	q.Follow = true
+	return ex.Stream(ctx, "journalctl", q.Args(opts)...)
+}
+
+// ReadJournal runs q and returns its entries oldest first.
+func ReadJournal(ctx context.Context, ex Executor, opts Options, q JournalQuery) ([]JournalEntry, error) {
+	res, err := ex.RunContext(ctx, "journalctl", q.Args(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("journalctl -u %s: %w", q.Unit, err)
+	}
+
+	var entries []JournalEntry
+	for _, line := range strings.Split(string(res.Stdout), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, err := ParseJournalJSON([]byte(line))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if q.Before != "" {
+		// --reverse printed them newest first
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	return entries, nil
+}
+
+// ParseJournalJSON parses one line of 'journalctl -o json' output.
+func ParseJournalJSON(line []byte) (JournalEntry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return JournalEntry{}, fmt.Errorf("parsing journal entry: %w", err)
+	}
+
+	entry := JournalEntry{
+		Priority:   DefaultJournalPriority,
+		Message:    journalField(raw, "MESSAGE"),
+		Cursor:     journalField(raw, "__CURSOR"),
+		Identifier: journalField(raw, "SYSLOG_IDENTIFIER"),
+	}
+	if entry.Identifier == "" {
+		entry.Identifier = journalField(raw, "_COMM")
+	}
+	if usec, err := strconv.ParseInt(journalField(raw, "__REALTIME_TIMESTAMP"), 10, 64); err == nil {
+		entry.Time = time.UnixMicro(usec)
+	}
+	if p, err := strconv.Atoi(journalField(raw, "PRIORITY")); err == nil {
+		entry.Priority = p
+	}
+	if pid, err := strconv.Atoi(journalField(raw, "_PID")); err == nil {
+		entry.PID = pid
+	} else if pid, err := strconv.Atoi(journalField(raw, "SYSLOG_PID")); err == nil {
+		entry.PID = pid
+	}
+	return entry, nil
+}
+
+// journalField returns a field as text. journalctl prints fields that are
+// not valid UTF-8 as arrays of bytes, and repeated fields as arrays of values
+// (of which the first is used).
+func journalField(raw map[string]json.RawMessage, name string) string {
+	data, ok := raw[name]
+	if !ok {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		return s
+	}
+	var b []byte
+	var ints []int
+	if json.Unmarshal(data, &ints) == nil {
+		for _, i := range ints {
+			b = append(b, byte(i))
+		}
+		return string(b)
+	}
+	var values []json.RawMessage
+	if json.Unmarshal(data, &values) == nil && len(values) > 0 {
+		return journalField(map[string]json.RawMessage{name: values[0]}, name)
+	}
+	return ""
+}
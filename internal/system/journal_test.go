@@ -0,0 +1,187 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJournalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want JournalEntry
+		err  bool
+	}{
+		{
+			name: "plain",
+			line: `{"__CURSOR":"s=1;i=2","__REALTIME_TIMESTAMP":"1704359520000000","PRIORITY":"4","_PID":"7","SYSLOG_IDENTIFIER":"sshd","MESSAGE":"hello"}`,
+			want: JournalEntry{Time: time.UnixMicro(1704359520000000), Priority: 4, PID: 7, Identifier: "sshd", Message: "hello", Cursor: "s=1;i=2"},
+		},
+		{
+			name: "defaults and fallbacks",
+			line: `{"_COMM":"bash","SYSLOG_PID":"9","MESSAGE":"x"}`,
+			want: JournalEntry{Priority: DefaultJournalPriority, PID: 9, Identifier: "bash", Message: "x"},
+		},
+		{
+			name: "message as bytes",
+			line: `{"MESSAGE":[27,91,49,109,98,111,108,100,27,91,48,109,255]}`,
+			want: JournalEntry{Priority: DefaultJournalPriority, Message: "\x1b[1mbold\x1b[0m\xff"},
+		},
+		{
+			name: "null field",
+			line: `{"MESSAGE":null,"PRIORITY":"2"}`,
+			want: JournalEntry{Priority: 2},
+		},
+		{
+			name: "unparsable numbers",
+			line: `{"PRIORITY":"high","_PID":"","__REALTIME_TIMESTAMP":"soon"}`,
+			want: JournalEntry{Priority: DefaultJournalPriority},
+		},
+		{name: "not json", line: `MESSAGE=hello`, err: true},
+		{name: "not an object", line: `["MESSAGE"]`, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJournalJSON([]byte(tt.line))
+			if (err != nil) != tt.err {
+				t.Fatalf("error %v, want error %v", err, tt.err)
+			}
+			if !got.Time.Equal(tt.want.Time) {
+				t.Errorf("time %v, want %v", got.Time, tt.want.Time)
+			}
+			got.Time, tt.want.Time = time.Time{}, time.Time{}
+			if got != tt.want {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJournalField(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string // raw JSON
+		want  string
+	}{
+		{"string", `"hi"`, "hi"},
+		{"bytes", `[104,105]`, "hi"},
+		{"invalid utf-8 bytes", `[255,254]`, "\xff\xfe"},
+		{"empty bytes", `[]`, ""},
+		{"repeated", `["first","second"]`, "first"},
+		{"repeated bytes", `[[104,105],"second"]`, "hi"},
+		{"repeated after bytes", `["first",[104,105]]`, "first"},
+		{"number", `42`, ""},
+		{"object", `{"a":1}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := map[string]json.RawMessage{"F": json.RawMessage(tt.value)}
+			if got := journalField(raw, "F"); got != tt.want {
+				t.Errorf("journalField(%s) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+	if got := journalField(map[string]json.RawMessage{}, "F"); got != "" {
+		t.Errorf("missing field = %q", got)
+	}
+}
+
+func TestJournalQueryArgs(t *testing.T) {
+	opts := Options{Scope: ScopeUser, Target: Target{Machine: "web"}}
+	tests := []struct {
+		name string
+		q    JournalQuery
+		want string
+	}{
+		{"tail", JournalQuery{Unit: "a.service", Limit: 3}, "--user -M web -u a.service -o json --no-pager -q -n 3"},
+		{"page back", JournalQuery{Unit: "a.service", Limit: 3, Before: "c"}, "--user -M web -u a.service -o json --no-pager -q -n 3 --reverse --after-cursor=c"},
+		{"follow from now", JournalQuery{Unit: "a.service", Follow: true}, "--user -M web -u a.service -o json --no-pager -q -n 0 -f"},
+		{"follow on", JournalQuery{Unit: "a.service", Follow: true, After: "c"}, "--user -M web -u a.service -o json --no-pager -q --after-cursor=c -f"},
+		{
+			"filters",
+			JournalQuery{Unit: "a.service", Priority: "err", Since: "-1h", Until: "now", Boot: "-1", Grep: "fail|oom"},
+			"--user -M web -u a.service -o json --no-pager -q -p err --since=-1h --until=now -b -1 -g fail|oom",
+		},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.q.Args(opts), " "); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestReadJournalPages reads the newest page of a unit's journal and then
+// the page before it, as the log viewer does, from recorded output.
+func TestReadJournalPages(t *testing.T) {
+	ex := NewFakeExecutor()
+	tail := JournalQuery{Unit: "nginx.service", Limit: 3}
+	before := JournalQuery{Unit: "nginx.service", Limit: 3, Before: "s=abc;i=104"}
+	if err := ex.OnFixture(filepath.Join("testdata", "journal-nginx-tail.json"), "journalctl", tail.Args(Options{})...); err != nil {
+		t.Fatal(err)
+	}
+	// --reverse prints the older page newest first
+	if err := ex.OnFixture(filepath.Join("testdata", "journal-nginx-before.json"), "journalctl", before.Args(Options{})...); err != nil {
+		t.Fatal(err)
+	}
+
+	newest, err := ReadJournal(context.Background(), ex, Options{}, tail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	older, err := ReadJournal(context.Background(), ex, Options{}, before)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := append(older, newest...)
+	var messages, cursors []string
+	for i, e := range all {
+		messages = append(messages, e.Message)
+		cursors = append(cursors, e.Cursor)
+		if i > 0 && !e.Time.After(all[i-1].Time) {
+			t.Errorf("entry %d (%s) is not newer than the one before", i, e.Time)
+		}
+	}
+	wantMessages := []string{"request 1", "request 2", "request 3", "request 4", "upstream \xff timed out", "request 6"}
+	if !reflect.DeepEqual(messages, wantMessages) {
+		t.Errorf("messages %q\nwant %q", messages, wantMessages)
+	}
+	if newest[0].Cursor != before.Before {
+		t.Errorf("older page read before %s, but the newest page starts at %s", before.Before, newest[0].Cursor)
+	}
+	if cursors[2] != "s=abc;i=103" {
+		t.Errorf("last entry of the older page has cursor %s", cursors[2])
+	}
+	if e := newest[1]; e.Priority != 3 || e.PID != 812 {
+		t.Errorf("byte-array entry: %+v", e)
+	}
+	if e := newest[2]; e.Identifier != "nginx" {
+		t.Errorf("repeated identifier read as %q", e.Identifier)
+	}
+}
+
+func TestReadJournalErrors(t *testing.T) {
+	q := JournalQuery{Unit: "a.service"}
+	ex := NewFakeExecutor()
+	ex.On(FakeResponse{Stdout: "{\"MESSAGE\":\"ok\"}\nnot json\n"}, "journalctl", q.Args(Options{})...)
+	if _, err := ReadJournal(context.Background(), ex, Options{}, q); err == nil {
+		t.Error("want an error for a garbled line")
+	}
+
+	ex = NewFakeExecutor()
+	ex.On(FakeResponse{Stderr: "Failed to open journal\n", ExitCode: 1}, "journalctl", q.Args(Options{})...)
+	if _, err := ReadJournal(context.Background(), ex, Options{}, q); err == nil || !strings.Contains(err.Error(), "journalctl -u a.service") {
+		t.Errorf("got %v, want an error naming the unit", err)
+	}
+
+	ex = NewFakeExecutor()
+	ex.On(FakeResponse{Stdout: "\n\n"}, "journalctl", q.Args(Options{})...)
+	if entries, err := ReadJournal(context.Background(), ex, Options{}, q); err != nil || len(entries) != 0 {
+		t.Errorf("empty journal: %v, %v", entries, err)
+	}
+}
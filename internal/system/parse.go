@@ -0,0 +1,101 @@
+// package system
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonUnit is one element of 'systemctl list-units --output=json'.
+type jsonUnit struct {
+	Unit        string `json:"unit"`
+	Load        string `json:"load"`
+	Active      string `json:"active"`
+	Sub         string `json:"sub"`
+	Description string `json:"description"`
+}
+
+// parseUnitsJSON parses the output of 'systemctl list-units --output=json'.
+func parseUnitsJSON(data []byte) ([]Unit, error) {
+	var raw []jsonUnit
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing systemctl JSON output: %w", err)
+	}
+
+	units := make([]Unit, 0, len(raw))
+	for _, u := range raw {
+		units = append(units, Unit{
+			Name:        u.Unit,
+			Load:        u.Load,
+			Active:      u.Active,
+			Sub:         u.Sub,
+			Description: u.Description,
+			Type:        unitTypeFromName(u.Unit),
+		})
+	}
+	return units, nil
+}
+
+// unitMarkers are the glyphs systemctl puts in front of units that failed or
+// have load problems: "●" in UTF-8 locales, "*" otherwise, and the newer
+// "○"/"×" state circles.
+var unitMarkers = map[string]bool{"●": true, "*": true, "○": true, "×": true}
+
+// parseUnitsText parses 'systemctl list-units --no-legend --plain' output:
+//
+//	[●] UNIT LOAD ACTIVE SUB [DESCRIPTION...]
+//
+// Blank lines are skipped and lines that don't fit are kept with a note in
+// the description rather than dropped, so nothing silently disappears.
+func parseUnitsText(text string) []Unit {
+	var units []Unit
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// Drop the failure marker, if any, before reading the columns
+		if first, rest := splitFields(line, 1); unitMarkers[first[0]] {
+			line = rest
+		}
+		fields, rest := splitFields(line, 4)
+		if len(fields) == 0 {
+			continue // nothing but the marker
+		}
+
+		if len(fields) < 4 {
+			// Handle lines with fewer than expected fields
+			units = append(units, Unit{Name: fields[0], Type: unitTypeFromName(fields[0]), Description: "Error parsing unit data"})
+			continue
+		}
+
+		units = append(units, Unit{
+			Name:        fields[0],
+			Load:        fields[1],
+			Active:      fields[2],
+			Sub:         fields[3],
+			Description: rest, // may contain (and keep) runs of spaces
+			Type:        unitTypeFromName(fields[0]),
+		})
+	}
+	return units
+}
+
+// splitFields returns up to n whitespace-separated fields from the start of
+// line and the trimmed remainder after them.
+func splitFields(line string, n int) ([]string, string) {
+	var fields []string
+	rest := strings.TrimLeft(line, " \t")
+	for len(fields) < n && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			fields = append(fields, rest)
+			rest = ""
+			break
+		}
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return fields, strings.TrimRight(rest, " \t\r")
+}
@@ -0,0 +1,188 @@
+package system
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// listUnitsArgs is the first argv FetchUnits tries.
+var listUnitsArgs = []string{"list-units", "--all", "--no-legend", "--plain", "--output=json"}
+
+// TestFetchUnitsFixtures replays 'systemctl list-units' output recorded on
+// several systemd versions. Versions before JSON support ignore --output
+// and print the plain table.
+func TestFetchUnitsFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		count   int
+		want    map[string]Unit // spot checks by name
+	}{
+		{
+			fixture: "list-units-v219.txt",
+			count:   9,
+			want: map[string]Unit{
+				"-.mount":            {Load: "loaded", Active: "active", Sub: "mounted", Description: "/", Type: "mount"},
+				"kdump.service":      {Load: "loaded", Active: "failed", Sub: "failed", Description: "Crash recovery kernel arming", Type: "service"},
+				"nfs-server.service": {Load: "not-found", Active: "inactive", Sub: "dead", Description: "nfs-server.service", Type: "service"},
+			},
+		},
+		{
+			fixture: "list-units-v245.txt",
+			count:   10,
+			want: map[string]Unit{
+				"multipathd.service": {Load: "loaded", Active: "failed", Sub: "failed", Description: "Device-Mapper Multipath Device Controller", Type: "service"},
+				"user@1000.service":  {Load: "loaded", Active: "active", Sub: "running", Description: "User Manager for UID 1000", Type: "service"},
+				"getty-pre.target":   {Load: "loaded", Active: "inactive", Sub: "dead", Description: "Login Prompts (Pre)", Type: "target"},
+			},
+		},
+		{
+			fixture: "list-units-v252.json",
+			count:   5,
+			want: map[string]Unit{
+				"postgresql@15-main.service":                    {Load: "loaded", Active: "failed", Sub: "failed", Description: "PostgreSQL Cluster 15-main", Type: "service"},
+				`systemd-fsck@dev-disk-by\x2duuid-1234.service`: {Load: "loaded", Active: "inactive", Sub: "dead", Description: "File System Check on /dev/disk/by-uuid/1234", Type: "service"},
+			},
+		},
+		{
+			fixture: "list-units-v255.txt",
+			count:   7,
+			want: map[string]Unit{
+				"fwupd-refresh.service": {Load: "loaded", Active: "failed", Sub: "failed", Description: "Refresh fwupd metadata and update motd", Type: "service"},
+				"plymouth-quit.service": {Load: "loaded", Active: "inactive", Sub: "dead", Description: "Terminate Plymouth Boot Screen", Type: "service"},
+				"home-user-data.mount":  {Load: "not-found", Active: "inactive", Sub: "dead", Description: "home-user-data.mount", Type: "mount"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			ex := NewFakeExecutor()
+			if err := ex.OnFixture(filepath.Join("testdata", tt.fixture), "systemctl", listUnitsArgs...); err != nil {
+				t.Fatal(err)
+			}
+			units, err := FetchUnits(context.Background(), ex)
+			if err != nil {
+				t.Fatalf("FetchUnits: %v", err)
+			}
+			if len(units) != tt.count {
+				t.Errorf("got %d units, want %d", len(units), tt.count)
+			}
+			byName := make(map[string]Unit)
+			for _, u := range units {
+				byName[u.Name] = u
+			}
+			for name, want := range tt.want {
+				want.Name = name
+				if got := byName[name]; got != want {
+					t.Errorf("unit %s:\n got %+v\nwant %+v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnitsText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Unit
+	}{
+		{"empty", "", nil},
+		{"blank lines", "\n  \n\t\n", nil},
+		{"marker only", "●\n", nil},
+		{"marker and spaces", "  ×   \n", nil},
+		{
+			name: "short line kept",
+			in:   "broken.service loaded\n",
+			want: []Unit{{Name: "broken.service", Type: "service", Description: "Error parsing unit data"}},
+		},
+		{
+			name: "description keeps inner spaces",
+			in:   "a.service loaded active running Two  spaces\r\n",
+			want: []Unit{{Name: "a.service", Load: "loaded", Active: "active", Sub: "running", Description: "Two  spaces", Type: "service"}},
+		},
+		{
+			name: "no description",
+			in:   "* b.socket loaded failed failed\n",
+			want: []Unit{{Name: "b.socket", Load: "loaded", Active: "failed", Sub: "failed", Type: "socket"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseUnitsText(tt.in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d units %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("unit %d:\n got %+v\nwant %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// FuzzParseUnitsText checks that any output, however mangled, parses
+// without panicking into at most one named unit per non-blank line.
+func FuzzParseUnitsText(f *testing.F) {
+	for _, name := range []string{"list-units-v219.txt", "list-units-v245.txt", "list-units-v255.txt"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(data))
+	}
+	f.Add("●\n")
+	f.Add("* \n○\t\n")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		units := parseUnitsText(text)
+		lines := 0
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) != "" {
+				lines++
+			}
+		}
+		if len(units) > lines {
+			t.Fatalf("%d units from %d lines", len(units), lines)
+		}
+		for _, u := range units {
+			if u.Name == "" {
+				t.Fatalf("unit without a name: %+v", u)
+			}
+		}
+	})
+}
+
+func TestFetchUnitsRetry(t *testing.T) {
+	plain := listUnitsArgs[:len(listUnitsArgs)-1]
+	tests := []struct {
+		name   string
+		stderr string
+		calls  int
+	}{
+		{"option rejected", "systemctl: unrecognized option '--output=json'\n", 2},
+		{"output type rejected", "Unknown output 'json'.\n", 2},
+		{"unreachable host", "ssh: connect to host web2 port 22: No route to host\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := NewFakeExecutor()
+			ex.On(FakeResponse{Stderr: tt.stderr, ExitCode: 1}, "systemctl", listUnitsArgs...)
+			ex.On(FakeResponse{Stdout: "a.service loaded active running A\n"}, "systemctl", plain...)
+			units, err := FetchUnits(context.Background(), ex)
+			if got := len(ex.Calls()); got != tt.calls {
+				t.Errorf("systemctl run %d times, want %d", got, tt.calls)
+			}
+			if tt.calls == 2 && (err != nil || len(units) != 1) {
+				t.Errorf("retry: got %v, %v", units, err)
+			}
+			if tt.calls == 1 && err == nil {
+				t.Error("want an error from the unreachable host")
+			}
+		})
+	}
+}
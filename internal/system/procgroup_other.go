@@ -0,0 +1,10 @@
+//go:build !unix
+
+// package system
+package system
+
+import "os/exec"
+
+// killGroupOnCancel is a no-op where process groups don't exist; cancelling
+// the context kills only the command itself.
+func killGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,23 @@
+//go:build unix
+
+// package system
+package system
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killGroupOnCancel runs cmd in its own process group and makes cancelling
+// its context kill the whole group, so helpers it spawned (ssh for -H,
+// pagers, polkit agents) don't outlive it.
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative pid signals every process in the group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Don't wait forever for pipes held open by a process that escaped the group
+	cmd.WaitDelay = 2 * time.Second
+}
@@ -0,0 +1,133 @@
+//go:build unix
+
+package system
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"systemctltui/internal/messages"
+)
+
+// spawnScript starts a child that outlives the shell unless its whole
+// process group is killed, and prints the child's PID.
+const spawnScript = "sleep 60 & echo $!; wait"
+
+// processGone reports whether pid has been killed: it no longer exists, or
+// is a zombie nobody reaped yet.
+func processGone(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return true
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	return err == nil && strings.Contains(string(stat), ") Z ")
+}
+
+func waitGone(t *testing.T, pid int) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if processGone(pid) {
+			return
+		}
+	}
+	_ = syscall.Kill(pid, syscall.SIGKILL)
+	t.Errorf("child %d survived its command", pid)
+}
+
+// TestStreamKillsProcessGroup cancels a streamed command, and lets another
+// time out, and checks that each ends with its own status and takes the
+// child it spawned with it.
+func TestStreamKillsProcessGroup(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	tests := []struct {
+		name    string
+		timeout time.Duration // zero: cancelled by hand instead
+		want    messages.CommandStatus
+	}{
+		{"cancelled", 0, messages.CommandCancelled},
+		{"timed out", 300 * time.Millisecond, messages.CommandTimedOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+			}
+			defer cancel()
+
+			first := StreamCommandAsync(ctx, 7, NewExecExecutor(), "sh", "-c", spawnScript)()
+			out, ok := first.(messages.CommandOutputMsg)
+			if !ok {
+				t.Fatalf("first message %#v, want the child's PID", first)
+			}
+			pid, err := strconv.Atoi(out.Line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.timeout == 0 {
+				cancel()
+			}
+
+			done := make(chan messages.CommandFinishedMsg, 1)
+			go func() {
+				msg := out.Next()
+				for {
+					if next, ok := msg.(messages.CommandOutputMsg); ok {
+						msg = next.Next()
+						continue
+					}
+					done <- msg.(messages.CommandFinishedMsg)
+					return
+				}
+			}()
+			select {
+			case fin := <-done:
+				if fin.ID != 7 || fin.Status != tt.want || fin.Err == nil {
+					t.Errorf("finished %+v, want ID 7 %s with an error", fin, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the command was not killed")
+			}
+			waitGone(t, pid)
+		})
+	}
+}
+
+// TestRunContextKillsProcessGroup checks that a timed-out command returns
+// at once: its child holds the output pipe open, so if the child survived,
+// RunContext would wait for it.
+func TestRunContextKillsProcessGroup(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewExecExecutor().RunContext(ctx, "sh", "-c", "sleep 60 & echo $! > "+pidFile+"; wait")
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("returned after %s", elapsed)
+	}
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("got %v, want the command killed at the deadline", err)
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitGone(t, pid)
+}
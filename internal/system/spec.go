@@ -0,0 +1,140 @@
+// package system
+package system
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/messages"
+)
+
+// UnitRule says whether a verb takes unit names.
+type UnitRule int
+
+const (
+	UnitNone     UnitRule = iota // never takes a unit (list-units, --version, ...)
+	UnitOptional                 // works with or without one (status, is-active, ...)
+	UnitRequired                 // fails without one (start, stop, ...)
+)
+
+// CommandSpec is one command as the UI previews and runs it. The preview is
+// rendered from it and the very same argument vector is executed, so what
+// the user confirms is what runs.
+type CommandSpec struct {
+	Binary      string   // "systemctl", or another program run through the Executor
+	Flags       []string // global flags from Options.Flags; a Backend adds its own
+	Verb        string
+	Args        []string // options of the verb, e.g. --now
+	Units       []string
+	UnitRule    UnitRule
+	NeedsRoot   bool // changes system state; expect a polkit prompt or a refusal when not root
+	Destructive bool // see Verb.Destructive
+}
+
+// NewSystemctlSpec describes 'systemctl verb units...' against the manager
+// selected by opts. Its unit rule and privileges come from the verb's
+// catalogue entry (see LookupVerb).
+func NewSystemctlSpec(opts Options, verb string, units ...string) CommandSpec {
+	info, _ := LookupVerb(verb)
+	return CommandSpec{
+		Binary:      "systemctl",
+		Flags:       opts.Flags(),
+		Verb:        verb,
+		Units:       units,
+		UnitRule:    info.UnitRule,
+		NeedsRoot:   opts.Scope == ScopeSystem && !info.ReadOnly,
+		Destructive: info.Destructive,
+	}
+}
+
+// Validate reports a spec that would fail before anything runs, such as a
+// missing unit or an option the verb does not accept.
+func (s CommandSpec) Validate() error {
+	if s.Binary == "systemctl" {
+		info, _ := LookupVerb(s.Verb)
+		for _, arg := range s.Args {
+			if !info.AcceptsOption(arg) {
+				return fmt.Errorf("command '%s' does not accept %s", s.Verb, arg)
+			}
+		}
+	}
+	switch {
+	case s.Verb == "":
+		return fmt.Errorf("no command specified")
+	case s.UnitRule == UnitRequired && len(s.Units) == 0:
+		return fmt.Errorf("command '%s' requires a unit", s.Verb)
+	case s.UnitRule == UnitNone && len(s.Units) > 0:
+		return fmt.Errorf("command '%s' does not take a unit", s.Verb)
+	}
+	return nil
+}
+
+// BackendArgs is the argument vector for Backend.Run and Backend.Stream:
+// verb first, without the global flags the Backend adds itself. The units
+// follow a "--" so a name starting with a dash is never taken for an option.
+func (s CommandSpec) BackendArgs() []string {
+	args := append([]string{s.Verb}, s.Args...)
+	if len(s.Units) == 0 {
+		return args
+	}
+	args = append(args, "--")
+	return append(args, s.Units...)
+}
+
+// Argv is the full command line, binary first.
+func (s CommandSpec) Argv() []string {
+	argv := append([]string{s.Binary}, s.Flags...)
+	return append(argv, s.BackendArgs()...)
+}
+
+// String is the command line shell-quoted, ready to paste into a terminal.
+func (s CommandSpec) String() string {
+	return ShellQuote(s.Argv())
+}
+
+// ShellQuote joins args into one line a POSIX shell splits back into args.
+// Words made only of safe characters are left bare; the rest are single-quoted.
+func ShellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuoteWord(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuoteWord(s string) string {
+	if s == "" {
+		return "''"
+	}
+	for _, r := range s {
+		if !isShellSafe(r) {
+			return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+		}
+	}
+	return s
+}
+
+func isShellSafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("@%+=:,./_-", r)
+}
+
+// StreamSpecAsync validates spec and runs it like StreamCommandAsync:
+// systemctl commands through b, anything else through ex.
+func StreamSpecAsync(ctx context.Context, id int, b Backend, ex Executor, spec CommandSpec) tea.Cmd {
+	if err := spec.Validate(); err != nil {
+		return func() tea.Msg {
+			return messages.CommandFinishedMsg{ID: id, Err: err, Status: commandStatus(ctx)}
+		}
+	}
+	if spec.Binary == "systemctl" {
+		return StreamBackendAsync(ctx, id, b, spec.BackendArgs()...)
+	}
+	argv := spec.Argv()
+	return StreamCommandAsync(ctx, id, ex, argv[0], argv[1:]...)
+}
@@ -0,0 +1,244 @@
+package system
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"systemctltui/internal/messages"
+)
+
+func TestVerbCatalogue(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, list := range [][]Verb{Verbs, hiddenVerbs} {
+		for _, v := range list {
+			if seen[v.Name] {
+				t.Errorf("verb %s listed twice", v.Name)
+			}
+			seen[v.Name] = true
+			if got, ok := LookupVerb(v.Name); !ok || got.Name != v.Name {
+				t.Errorf("LookupVerb(%q) = %+v, %v", v.Name, got, ok)
+			}
+			for _, o := range v.Options {
+				if _, ok := options[o]; !ok {
+					t.Errorf("verb %s: option %s is not described", v.Name, o)
+				}
+			}
+			if v.ReadOnly && v.Destructive {
+				t.Errorf("verb %s is both read-only and destructive", v.Name)
+			}
+		}
+	}
+	for _, v := range hiddenVerbs {
+		if v.UnitRule == UnitRequired {
+			t.Errorf("hidden verb %s requires a unit; the UI never passes one", v.Name)
+		}
+	}
+
+	unknown, ok := LookupVerb("frobnicate")
+	if ok || unknown.UnitRule != UnitRequired || unknown.ReadOnly {
+		t.Errorf("unknown verb described as %+v, %v", unknown, ok)
+	}
+}
+
+func TestAcceptsOption(t *testing.T) {
+	kill, _ := LookupVerb("kill")
+	tests := []struct {
+		verb   Verb
+		option string
+		want   bool
+	}{
+		{kill, "--signal=SIGHUP", true},
+		{kill, "--signal", true},
+		{kill, "--kill-whom=main", true},
+		{kill, "--now", false},
+		{kill, "--signalx=1", false},
+		{kill, "--signal=SIGRTMIN+3", true},
+		{kill, "--signal=", false},
+		{kill, "--signal=a=b", true},        // only the first '=' separates the value
+		{Verbs[1], "--no-block=yes", false}, // start; a plain flag
+		{Verbs[0], "--no-block", false},     // status
+	}
+	for _, tt := range tests {
+		if got := tt.verb.AcceptsOption(tt.option); got != tt.want {
+			t.Errorf("%s accepts %s = %v, want %v", tt.verb.Name, tt.option, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	opts := Options{}
+	withArgs := func(s CommandSpec, args ...string) CommandSpec {
+		s.Args = args
+		return s
+	}
+	tests := []struct {
+		name string
+		spec CommandSpec
+		err  string // substring; "" for valid
+	}{
+		{"start with unit", NewSystemctlSpec(opts, "start", "nginx.service"), ""},
+		{"start without unit", NewSystemctlSpec(opts, "start"), "requires a unit"},
+		{"status without unit", NewSystemctlSpec(opts, "status"), ""},
+		{"reset-failed without unit", NewSystemctlSpec(opts, "reset-failed"), ""},
+		{"list-units with unit", NewSystemctlSpec(opts, "list-units", "a.service"), "does not take a unit"},
+		{"unknown verb needs a unit", NewSystemctlSpec(opts, "frobnicate"), "requires a unit"},
+		{"no verb", CommandSpec{Binary: "systemctl"}, "no command"},
+		{"accepted option", withArgs(NewSystemctlSpec(opts, "enable", "a.service"), "--now"), ""},
+		{"option with value", withArgs(NewSystemctlSpec(opts, "kill", "a.service"), "--signal=SIGHUP"), ""},
+		{"rejected option", withArgs(NewSystemctlSpec(opts, "start", "a.service"), "--now"), "does not accept --now"},
+		{"other binary options unchecked", CommandSpec{Binary: "journalctl", Verb: "-u", Args: []string{"--anything"}, Units: []string{"a"}, UnitRule: UnitRequired}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Validate()
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"systemctl", "start", "nginx.service"}, "systemctl start nginx.service"},
+		{[]string{"systemctl", "-H", "root@web1:2222"}, "systemctl -H root@web1:2222"},
+		{[]string{"systemctl", "--signal=SIGHUP"}, "systemctl --signal=SIGHUP"},
+		{[]string{""}, "''"},
+		{[]string{"two words"}, "'two words'"},
+		{[]string{"it's"}, `'it'\''s'`},
+		{[]string{`systemd-fsck@dev-disk-by\x2duuid.service`}, `'systemd-fsck@dev-disk-by\x2duuid.service'`},
+		{[]string{"a;rm -rf /"}, "'a;rm -rf /'"},
+		{[]string{"$HOME", "`id`", "*"}, "'$HOME' '`id`' '*'"},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.args); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestArgv checks the command line of every verb, as previewed and run.
+func TestArgv(t *testing.T) {
+	opts := Options{Scope: ScopeUser, Target: Target{Host: "root@web1"}}
+	flags := []string{"systemctl", "--user", "-H", "root@web1"}
+	for _, list := range [][]Verb{Verbs, hiddenVerbs} {
+		for _, v := range list {
+			t.Run(v.Name, func(t *testing.T) {
+				var spec CommandSpec
+				var want []string
+				if v.UnitRule == UnitNone {
+					spec = NewSystemctlSpec(opts, v.Name)
+					want = append(append([]string{}, flags...), v.Name)
+				} else {
+					spec = NewSystemctlSpec(opts, v.Name, "nginx.service", "-weird.service")
+					want = append(append([]string{}, flags...), v.Name, "--", "nginx.service", "-weird.service")
+				}
+				if err := spec.Validate(); err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				if got := spec.Argv(); !reflect.DeepEqual(got, want) {
+					t.Errorf("Argv = %q, want %q", got, want)
+				}
+				if got := spec.BackendArgs(); !reflect.DeepEqual(got, want[len(flags):]) {
+					t.Errorf("BackendArgs = %q, want %q", got, want[len(flags):])
+				}
+				if spec.NeedsRoot {
+					t.Error("a user manager never needs root")
+				}
+			})
+		}
+	}
+
+	spec := NewSystemctlSpec(Options{}, "kill", "a.service")
+	spec.Args = []string{"--signal=SIGHUP"}
+	want := "systemctl kill --signal=SIGHUP -- a.service"
+	if got := spec.String(); got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+	if !spec.NeedsRoot || !spec.Destructive {
+		t.Errorf("system kill: NeedsRoot %v, Destructive %v", spec.NeedsRoot, spec.Destructive)
+	}
+}
+
+func TestHasOptions(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"a.service"}, false},
+		{[]string{"--no-block", "a.service"}, true},
+		{[]string{"--", "-weird.service"}, false},
+		{[]string{"--now", "--", "a.service"}, true},
+	}
+	for _, tt := range tests {
+		if got := hasOptions(tt.args); got != tt.want {
+			t.Errorf("hasOptions(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+	if got := operands([]string{"--", "-weird.service"}); !reflect.DeepEqual(got, []string{"-weird.service"}) {
+		t.Errorf("operands = %q", got)
+	}
+}
+
+// TestStreamSpecStatus checks how the context a spec ran under shows in its
+// CommandFinishedMsg: a cancelled one as cancelled, an expired one as timed
+// out, and otherwise the command's own result.
+func TestStreamSpecStatus(t *testing.T) {
+	ex := NewFakeExecutor()
+	ex.On(FakeResponse{Stdout: "stopping\n", ExitCode: 3}, "systemctl", "stop", "--", "a.service")
+	ex.On(FakeResponse{}, "journalctl", "-u", "a.service", "-f")
+	backend := NewSystemctlBackend(ex, Options{})
+	stop := NewSystemctlSpec(Options{}, "stop", "a.service")
+	follow := CommandSpec{Binary: "journalctl", Verb: "-u", Units: []string{"a.service"}, Args: []string{"-f"}, UnitRule: UnitRequired}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		spec CommandSpec
+		want messages.CommandStatus
+	}{
+		{"completed", context.Background(), stop, messages.CommandCompleted},
+		{"cancelled", cancelled, stop, messages.CommandCancelled},
+		{"timed out", expired, stop, messages.CommandTimedOut},
+		{"other binary cancelled", cancelled, follow, messages.CommandCancelled},
+		{"other binary timed out", expired, follow, messages.CommandTimedOut},
+		{"invalid spec cancelled", cancelled, NewSystemctlSpec(Options{}, "start"), messages.CommandCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := StreamSpecAsync(tt.ctx, 5, backend, ex, tt.spec)()
+			for {
+				out, ok := msg.(messages.CommandOutputMsg)
+				if !ok {
+					break
+				}
+				msg = out.Next()
+			}
+			fin, ok := msg.(messages.CommandFinishedMsg)
+			if !ok {
+				t.Fatalf("got %#v", msg)
+			}
+			if fin.ID != 5 || fin.Status != tt.want {
+				t.Errorf("finished %+v, want %s", fin, tt.want)
+			}
+			if tt.want == messages.CommandCompleted && fin.ExitCode != 3 {
+				t.Errorf("exit status %d, want 3", fin.ExitCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// package system
+package system
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdUnitIface   = "org.freedesktop.systemd1.Unit"
+	propertiesIface    = "org.freedesktop.DBus.Properties"
+	systemdUnitPathDir = dbus.ObjectPath("/org/freedesktop/systemd1/unit")
+)
+
+// UnitEventKind says what happened to a unit.
+type UnitEventKind int
+
+const (
+	UnitAdded   UnitEventKind = iota // a unit was loaded (UnitNew)
+	UnitRemoved                      // a unit was unloaded (UnitRemoved)
+	UnitChanged                      // a unit's state changed (PropertiesChanged, JobRemoved)
+)
+
+// UnitEvent is an incremental change to the unit list.
+// For UnitChanged events only the fields that changed are set; empty
+// Load/Active/Sub/Description fields mean "unchanged".
+type UnitEvent struct {
+	Kind UnitEventKind
+	Unit Unit
+}
+
+// Apply merges a UnitChanged event into u and returns the result.
+func (e UnitEvent) Apply(u Unit) Unit {
+	if e.Unit.Load != "" {
+		u.Load = e.Unit.Load
+	}
+	if e.Unit.Active != "" {
+		u.Active = e.Unit.Active
+	}
+	if e.Unit.Sub != "" {
+		u.Sub = e.Unit.Sub
+	}
+	if e.Unit.Description != "" {
+		u.Description = e.Unit.Description
+	}
+	return u
+}
+
+// Subscriber is implemented by backends that can push unit changes as they
+// happen instead of being polled.
+type Subscriber interface {
+	// Subscribe delivers events until ctx is done, then closes the channel.
+	Subscribe(ctx context.Context) (<-chan UnitEvent, error)
+}
+
+// Subscribe implements Subscriber by listening for the manager's UnitNew,
+// UnitRemoved and JobRemoved signals and for PropertiesChanged on unit objects.
+func (b *DBusBackend) Subscribe(ctx context.Context) (<-chan UnitEvent, error) {
+	// Without Subscribe the manager does not emit most of its signals.
+	if err := b.call(ctx, "Subscribe").Err; err != nil {
+		return nil, managerError(err)
+	}
+
+	matches := [][]dbus.MatchOption{
+		{
+			dbus.WithMatchObjectPath(systemdObjectPath),
+			dbus.WithMatchInterface(systemdManagerIface),
+		},
+		{
+			dbus.WithMatchPathNamespace(systemdUnitPathDir),
+			dbus.WithMatchInterface(propertiesIface),
+			dbus.WithMatchMember("PropertiesChanged"),
+			dbus.WithMatchArg(0, systemdUnitIface),
+		},
+	}
+	for _, opts := range matches {
+		if err := b.conn.AddMatchSignalContext(ctx, opts...); err != nil {
+			return nil, fmt.Errorf("adding D-Bus match: %w", err)
+		}
+	}
+
+	signals := make(chan *dbus.Signal, 64)
+	b.conn.Signal(signals)
+
+	events := make(chan UnitEvent)
+	go func() {
+		defer close(events)
+		defer func() {
+			b.conn.RemoveSignal(signals)
+			for _, opts := range matches {
+				_ = b.conn.RemoveMatchSignal(opts...)
+			}
+			_ = b.call(context.Background(), "Unsubscribe").Err
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig, ok := <-signals:
+				if !ok {
+					return
+				}
+				ev, ok := b.eventFromSignal(ctx, sig)
+				if !ok {
+					continue
+				}
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// eventFromSignal translates one D-Bus signal into a UnitEvent. It reports
+// false for signals that carry nothing the unit list cares about.
+func (b *DBusBackend) eventFromSignal(ctx context.Context, sig *dbus.Signal) (UnitEvent, bool) {
+	switch sig.Name {
+	case systemdManagerIface + ".UnitNew":
+		var name string
+		var path dbus.ObjectPath
+		if dbus.Store(sig.Body, &name, &path) != nil {
+			return UnitEvent{}, false
+		}
+		unit, err := b.unitAt(ctx, path)
+		if err != nil {
+			unit = Unit{Name: name, Type: unitTypeFromName(name)}
+		}
+		return UnitEvent{Kind: UnitAdded, Unit: unit}, true
+
+	case systemdManagerIface + ".UnitRemoved":
+		var name string
+		var path dbus.ObjectPath
+		if dbus.Store(sig.Body, &name, &path) != nil {
+			return UnitEvent{}, false
+		}
+		return UnitEvent{Kind: UnitRemoved, Unit: Unit{Name: name}}, true
+
+	case systemdManagerIface + ".JobRemoved":
+		var id uint32
+		var job dbus.ObjectPath
+		var name, result string
+		if dbus.Store(sig.Body, &id, &job, &name, &result) != nil {
+			return UnitEvent{}, false
+		}
+		// The job result alone does not say what state the unit ended in.
+		var path dbus.ObjectPath
+		if err := b.call(ctx, "GetUnit", name).Store(&path); err != nil {
+			return UnitEvent{}, false
+		}
+		unit, err := b.unitAt(ctx, path)
+		if err != nil {
+			return UnitEvent{}, false
+		}
+		return UnitEvent{Kind: UnitChanged, Unit: unit}, true
+
+	case propertiesIface + ".PropertiesChanged":
+		var iface string
+		var changed map[string]dbus.Variant
+		var invalidated []string
+		if dbus.Store(sig.Body, &iface, &changed, &invalidated) != nil || iface != systemdUnitIface {
+			return UnitEvent{}, false
+		}
+		unit := unitFromProperties(changed)
+		if unit.Name == "" {
+			unit.Name = unitNameFromPath(sig.Path)
+		}
+		if unit.Load == "" && unit.Active == "" && unit.Sub == "" && unit.Description == "" {
+			return UnitEvent{}, false
+		}
+		return UnitEvent{Kind: UnitChanged, Unit: unit}, true
+	}
+	return UnitEvent{}, false
+}
+
+// unitAt reads the list-relevant properties of the unit object at path.
+func (b *DBusBackend) unitAt(ctx context.Context, path dbus.ObjectPath) (Unit, error) {
+	var props map[string]dbus.Variant
+	err := b.conn.Object(systemdBusName, path).
+		CallWithContext(ctx, propertiesIface+".GetAll", 0, systemdUnitIface).
+		Store(&props)
+	if err != nil {
+		return Unit{}, managerError(err)
+	}
+	unit := unitFromProperties(props)
+	if unit.Name == "" {
+		unit.Name = unitNameFromPath(path)
+	}
+	unit.Type = unitTypeFromName(unit.Name)
+	return unit, nil
+}
+
+// unitFromProperties picks the Unit fields out of a D-Bus property map.
+func unitFromProperties(props map[string]dbus.Variant) Unit {
+	str := func(key string) string {
+		if v, ok := props[key]; ok {
+			if s, ok := v.Value().(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+	return Unit{
+		Name:        str("Id"),
+		Load:        str("LoadState"),
+		Active:      str("ActiveState"),
+		Sub:         str("SubState"),
+		Description: str("Description"),
+	}
+}
+
+// unitNameFromPath reverses systemd's object path escaping, e.g.
+// /org/freedesktop/systemd1/unit/nginx_2eservice -> nginx.service.
+func unitNameFromPath(path dbus.ObjectPath) string {
+	label := strings.TrimPrefix(string(path), string(systemdUnitPathDir)+"/")
+	var out strings.Builder
+	for i := 0; i < len(label); i++ {
+		if label[i] == '_' && i+2 < len(label) {
+			if c, err := strconv.ParseUint(label[i+1:i+3], 16, 8); err == nil {
+				out.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		out.WriteByte(label[i])
+	}
+	return out.String()
+}
@@ -0,0 +1,242 @@
+package system
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestUnitNameFromPath(t *testing.T) {
+	tests := []struct {
+		path dbus.ObjectPath
+		want string
+	}{
+		{"/org/freedesktop/systemd1/unit/nginx_2eservice", "nginx.service"},
+		{"/org/freedesktop/systemd1/unit/_2d_2eslice", "-.slice"},
+		{"/org/freedesktop/systemd1/unit/user_401000_2eservice", "user@1000.service"},
+		{"/org/freedesktop/systemd1/unit/systemd_2dfsck_40dev_2ddisk_2dby_5cx2duuid_2eservice", `systemd-fsck@dev-disk-by\x2duuid.service`},
+		{"/org/freedesktop/systemd1/unit/a_5fb_2etimer", "a_b.timer"},
+		{"/org/freedesktop/systemd1/unit/trailing_2", "trailing_2"}, // cut-off escape kept as is
+		{"/org/freedesktop/systemd1/unit/bad_zzx", "bad_zzx"},
+	}
+	for _, tt := range tests {
+		if got := unitNameFromPath(tt.path); got != tt.want {
+			t.Errorf("unitNameFromPath(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+		if tt.want != "trailing_2" && tt.want != "bad_zzx" && unitPath(tt.want) != tt.path {
+			t.Errorf("unitPath(%q) = %s, want %s", tt.want, unitPath(tt.want), tt.path)
+		}
+	}
+}
+
+// TestEventFromSignal covers the signals that are translated without asking
+// the manager anything, and signals that must be ignored.
+func TestEventFromSignal(t *testing.T) {
+	b := &DBusBackend{} // no connection: none of these may use it
+	props := func(kv ...string) map[string]dbus.Variant {
+		m := make(map[string]dbus.Variant)
+		for i := 0; i < len(kv); i += 2 {
+			m[kv[i]] = dbus.MakeVariant(kv[i+1])
+		}
+		return m
+	}
+	changed := func(path dbus.ObjectPath, iface string, m map[string]dbus.Variant) *dbus.Signal {
+		return &dbus.Signal{Path: path, Name: propertiesIface + ".PropertiesChanged", Body: []interface{}{iface, m, []string{}}}
+	}
+
+	tests := []struct {
+		name string
+		sig  *dbus.Signal
+		want UnitEvent
+		ok   bool
+	}{
+		{
+			name: "unit removed",
+			sig:  &dbus.Signal{Name: systemdManagerIface + ".UnitRemoved", Body: []interface{}{"a.service", unitPath("a.service")}},
+			want: UnitEvent{Kind: UnitRemoved, Unit: Unit{Name: "a.service"}},
+			ok:   true,
+		},
+		{
+			name: "state changed",
+			sig:  changed(unitPath("a.service"), systemdUnitIface, props("ActiveState", "failed", "SubState", "failed")),
+			want: UnitEvent{Kind: UnitChanged, Unit: Unit{Name: "a.service", Active: "failed", Sub: "failed"}},
+			ok:   true,
+		},
+		{
+			name: "description changed with Id",
+			sig:  changed(unitPath("alias.service"), systemdUnitIface, props("Id", "real.service", "Description", "Real")),
+			want: UnitEvent{Kind: UnitChanged, Unit: Unit{Name: "real.service", Description: "Real"}},
+			ok:   true,
+		},
+		{
+			name: "nothing the list shows",
+			sig:  changed(unitPath("a.service"), systemdUnitIface, props("ActiveEnterTimestamp", "x")),
+		},
+		{
+			name: "service interface",
+			sig:  changed(unitPath("a.service"), "org.freedesktop.systemd1.Service", props("ActiveState", "active")),
+		},
+		{
+			name: "malformed unit removed",
+			sig:  &dbus.Signal{Name: systemdManagerIface + ".UnitRemoved", Body: []interface{}{uint32(1)}},
+		},
+		{
+			name: "malformed properties",
+			sig:  &dbus.Signal{Name: propertiesIface + ".PropertiesChanged", Body: []interface{}{"x"}},
+		},
+		{
+			name: "malformed job removed",
+			sig:  &dbus.Signal{Name: systemdManagerIface + ".JobRemoved", Body: []interface{}{"a.service"}},
+		},
+		{
+			name: "unrelated signal",
+			sig:  &dbus.Signal{Name: "org.freedesktop.DBus.NameAcquired", Body: []interface{}{":1.5"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := b.eventFromSignal(context.Background(), tt.sig)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("got %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUnitEventApply(t *testing.T) {
+	u := Unit{Name: "a.service", Load: "loaded", Active: "active", Sub: "running", Description: "A", Type: "service"}
+	got := UnitEvent{Kind: UnitChanged, Unit: Unit{Name: "a.service", Active: "deactivating"}}.Apply(u)
+	want := u
+	want.Active = "deactivating"
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// stubUnit is a unit object answering Properties.GetAll.
+type stubUnit struct {
+	props map[string]dbus.Variant
+}
+
+func (u *stubUnit) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if iface != systemdUnitIface {
+		return map[string]dbus.Variant{}, nil
+	}
+	return u.props, nil
+}
+
+// fakeEmitter plays the manager's side of a subscription: it holds unit
+// objects and emits the signals systemd would when they change.
+type fakeEmitter struct {
+	t    *testing.T
+	conn *dbus.Conn
+}
+
+// setUnit exports (or replaces) the unit object for u.
+func (e fakeEmitter) setUnit(u Unit) {
+	e.t.Helper()
+	obj := &stubUnit{props: map[string]dbus.Variant{
+		"Id":          dbus.MakeVariant(u.Name),
+		"LoadState":   dbus.MakeVariant(u.Load),
+		"ActiveState": dbus.MakeVariant(u.Active),
+		"SubState":    dbus.MakeVariant(u.Sub),
+		"Description": dbus.MakeVariant(u.Description),
+	}}
+	if err := e.conn.Export(obj, unitPath(u.Name), propertiesIface); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+func (e fakeEmitter) emit(path dbus.ObjectPath, name string, body ...interface{}) {
+	e.t.Helper()
+	if err := e.conn.Emit(path, name, body...); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+func (e fakeEmitter) unitNew(name string) {
+	e.emit(systemdObjectPath, systemdManagerIface+".UnitNew", name, unitPath(name))
+}
+
+func (e fakeEmitter) unitRemoved(name string) {
+	e.emit(systemdObjectPath, systemdManagerIface+".UnitRemoved", name, unitPath(name))
+}
+
+func (e fakeEmitter) jobRemoved(name, result string) {
+	e.emit(systemdObjectPath, systemdManagerIface+".JobRemoved", uint32(7), dbus.ObjectPath("/org/freedesktop/systemd1/job/7"), name, result)
+}
+
+func (e fakeEmitter) propertiesChanged(name string, changed map[string]dbus.Variant) {
+	e.emit(unitPath(name), propertiesIface+".PropertiesChanged", systemdUnitIface, changed, []string{})
+}
+
+// TestSubscribe drives a subscription from a fake emitter on a private bus.
+func TestSubscribe(t *testing.T) {
+	address := startBus(t)
+	stub := newStubManager(t, address, map[string]string{"a.service": "done"})
+	emitter := fakeEmitter{t: t, conn: stub.conn}
+	b, err := NewDBusBackend(Options{Backend: BackendDBus, DBusAddress: address}, NewFakeExecutor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := b.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() UnitEvent {
+		t.Helper()
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatal("event channel closed")
+			}
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event")
+		}
+		return UnitEvent{}
+	}
+
+	a := Unit{Name: "a.service", Load: "loaded", Active: "inactive", Sub: "dead", Description: "A", Type: "service"}
+	emitter.setUnit(a)
+	emitter.unitNew(a.Name)
+	if ev := next(); ev != (UnitEvent{Kind: UnitAdded, Unit: a}) {
+		t.Errorf("UnitNew: got %+v", ev)
+	}
+
+	emitter.propertiesChanged(a.Name, map[string]dbus.Variant{"ActiveState": dbus.MakeVariant("activating")})
+	if ev := next(); ev != (UnitEvent{Kind: UnitChanged, Unit: Unit{Name: a.Name, Active: "activating"}}) {
+		t.Errorf("PropertiesChanged: got %+v", ev)
+	}
+
+	// The job result does not say the state, so it is read back from the unit
+	a.Active, a.Sub = "active", "running"
+	emitter.setUnit(a)
+	emitter.jobRemoved(a.Name, "done")
+	if ev := next(); ev != (UnitEvent{Kind: UnitChanged, Unit: a}) {
+		t.Errorf("JobRemoved: got %+v", ev)
+	}
+
+	// A job of a unit the manager cannot find yields nothing; the removal
+	// after it is the next event
+	emitter.jobRemoved("gone.service", "done")
+	emitter.unitRemoved(a.Name)
+	if ev := next(); ev != (UnitEvent{Kind: UnitRemoved, Unit: Unit{Name: a.Name}}) {
+		t.Errorf("UnitRemoved: got %+v", ev)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("event after cancel")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("channel not closed after cancel")
+	}
+}
@@ -2,8 +2,9 @@
 package system
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 )
 
@@ -15,53 +16,117 @@ type Unit struct {
 	Sub         string
 	Description string
 	Type        string // e.g., "service", "device", "mount"
+	Host        string // Target.String() of the host it came from in the fleet view; empty otherwise
 }
 
-// FetchUnits calls 'systemctl list-units' and parses the output into structured Unit data.
-func FetchUnits() ([]Unit, error) {
+// Key identifies a unit across hosts, e.g. "web1/nginx.service".
+func (u Unit) Key() string {
+	if u.Host == "" {
+		return u.Name
+	}
+	return u.Host + "/" + u.Name
+}
+
+// FetchUnits calls 'systemctl list-units' through ex and parses the output into structured Unit data.
+// globalFlags (e.g. "--user") select the manager to ask; see Options.Flags.
+// The command is killed if ctx is done first.
+//
+// JSON output is requested where systemctl supports it. Versions that don't
+// either ignore --output for list-units and print the plain table (which is
+// parsed as text), or reject it, in which case the plain table is requested.
+func FetchUnits(ctx context.Context, ex Executor, globalFlags ...string) ([]Unit, error) {
 	// Use --no-legend to get just the data rows
 	// Use --plain to ensure consistent space separation
-	out, err := exec.Command("systemctl", "list-units", "--all", "--no-legend", "--plain").Output()
+	args := append(append([]string{}, globalFlags...), "list-units", "--all", "--no-legend", "--plain")
+
+	res, err := ex.RunContext(ctx, "systemctl", append(args, "--output=json")...)
+	if err != nil && ctx.Err() == nil && outputRejected(res) {
+		// Too old to know --output=json at all; retry without it
+		res, err = ex.RunContext(ctx, "systemctl", args...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute systemctl: %w", err)
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	units := make([]Unit, 0, len(lines))
+	if out := bytes.TrimSpace(res.Stdout); len(out) > 0 && out[0] == '[' {
+		return parseUnitsJSON(out)
+	}
+	return parseUnitsText(string(res.Stdout)), nil
+}
 
-	for _, line := range lines {
-		// Use strings.FieldsFunc to split by one or more spaces
-		fields := strings.Fields(line)
+// outputRejected reports whether systemctl failed because it does not know
+// --output=json, as opposed to e.g. an unreachable host, which retrying
+// would only wait on twice.
+func outputRejected(res Result) bool {
+	stderr := string(res.Stderr)
+	return strings.Contains(stderr, "--output") || strings.Contains(stderr, "Unknown output")
+}
 
-		if len(fields) >= 5 { // Expect at least 5 fields: UNIT LOAD ACTIVE SUB DESCRIPTION
-			unitName := fields[0]
+// unitTypeFromName extracts the unit type from the name suffix
+// (e.g., "service" for "nginx.service"), or "unknown" if there is none.
+func unitTypeFromName(unitName string) string {
+	if lastDot := strings.LastIndex(unitName, "."); lastDot != -1 && lastDot < len(unitName)-1 {
+		return unitName[lastDot+1:]
+	}
+	return "unknown"
+}
 
-			// Attempt to extract unit type from the name suffix (e.g., ".service")
-			unitType := "unknown"
-			if lastDot := strings.LastIndex(unitName, "."); lastDot != -1 && lastDot < len(unitName)-1 {
-				unitType = unitName[lastDot+1:]
-			}
+// TODO: Add other systemctl commands here (e.g., Status, Start, Stop)
+// func Status(unitName string) (string, error) { ... }
 
-			// The description might contain spaces, so join the remaining fields
-			description := strings.Join(fields[4:], " ")
+// ChangedUnits compares two unit snapshots and returns the keys (see Unit.Key)
+// of units in next that are new or whose Load/Active/Sub state differs from prev.
+func ChangedUnits(prev, next []Unit) map[string]bool {
+	before := make(map[string]Unit, len(prev))
+	for _, u := range prev {
+		before[u.Key()] = u
+	}
 
+	changed := make(map[string]bool)
+	for _, u := range next {
+		old, ok := before[u.Key()]
+		if !ok || old.Load != u.Load || old.Active != u.Active || old.Sub != u.Sub {
+			changed[u.Key()] = true
+		}
+	}
+	return changed
+}
 
-			unit := Unit{
-				Name:        unitName,
-				Load:        fields[1],
-				Active:      fields[2],
-				Sub:         fields[3],
-				Description: description,
-				Type:        unitType,
-			}
-			units = append(units, unit)
-		} else if len(fields) > 0 {
-             // Handle lines with fewer than expected fields (shouldn't happen with --plain?)
-             units = append(units, Unit{Name: fields[0], Description: "Error parsing unit data"})
-        }
+// SystemState returns the overall state of the manager selected by opts, as
+// 'systemctl is-system-running' reports it: running, degraded, maintenance,
+// starting, stopping, initializing or offline. systemctl exits non-zero for
+// every state but running, so the output counts, not the exit status.
+func SystemState(ctx context.Context, ex Executor, opts Options) (string, error) {
+	argv := append(append([]string{}, opts.Flags()...), "is-system-running")
+	res, err := ex.RunContext(ctx, "systemctl", argv...)
+	if state := strings.TrimSpace(string(res.Stdout)); state != "" {
+		return state, nil
 	}
-	return units, nil
+	if err != nil {
+		return "", fmt.Errorf("systemctl is-system-running: %w", err)
+	}
+	return "unknown", nil
 }
 
-// TODO: Add other systemctl commands here (e.g., Status, Start, Stop)
-// func Status(unitName string) (string, error) { ... }
+// UnitHealth counts units by the states the health header shows.
+type UnitHealth struct {
+	Running    int // sub-state running
+	Failed     int
+	Activating int // starting up or reloading
+}
+
+// CountHealth tallies units for the health header.
+func CountHealth(units []Unit) UnitHealth {
+	var h UnitHealth
+	for _, u := range units {
+		switch {
+		case u.Active == "failed":
+			h.Failed++
+		case u.Active == "activating" || u.Active == "reloading":
+			h.Activating++
+		case u.Sub == "running":
+			h.Running++
+		}
+	}
+	return h
+}
@@ -0,0 +1,74 @@
+package system
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSystemState(t *testing.T) {
+	tests := []struct {
+		name string
+		resp FakeResponse
+		want string
+		err  bool
+	}{
+		{"running", FakeResponse{Stdout: "running\n"}, "running", false},
+		// is-system-running exits non-zero for every state but running
+		{"degraded", FakeResponse{Stdout: "degraded\n", ExitCode: 1}, "degraded", false},
+		{"maintenance", FakeResponse{Stdout: "maintenance\n", ExitCode: 1}, "maintenance", false},
+		{"starting", FakeResponse{Stdout: "starting\n", ExitCode: 1}, "starting", false},
+		{"offline", FakeResponse{Stdout: "offline\n", ExitCode: 1}, "offline", false},
+		{"no answer", FakeResponse{Stderr: "Failed to connect to bus: No such file or directory\n", ExitCode: 1}, "", true},
+		{"empty output", FakeResponse{}, "unknown", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Scope: ScopeUser, Target: Target{Host: "web1"}}
+			ex := NewFakeExecutor()
+			ex.On(tt.resp, "systemctl", "--user", "-H", "web1", "is-system-running")
+			got, err := SystemState(context.Background(), ex, opts)
+			if got != tt.want || (err != nil) != tt.err {
+				t.Errorf("got %q, %v; want %q, error %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestCountHealth(t *testing.T) {
+	tests := []struct {
+		name  string
+		units []Unit
+		want  UnitHealth
+	}{
+		{"none", nil, UnitHealth{}},
+		{
+			"each state",
+			[]Unit{
+				{Active: "active", Sub: "running"},
+				{Active: "active", Sub: "running"},
+				{Active: "active", Sub: "exited"},
+				{Active: "failed", Sub: "failed"},
+				{Active: "activating", Sub: "start-pre"},
+				{Active: "reloading", Sub: "reload"},
+				{Active: "inactive", Sub: "dead"},
+			},
+			UnitHealth{Running: 2, Failed: 1, Activating: 2},
+		},
+		{
+			// A unit counts once, by its active state first
+			"failed wins over running",
+			[]Unit{{Active: "failed", Sub: "running"}, {Active: "activating", Sub: "running"}},
+			UnitHealth{Failed: 1, Activating: 1},
+		},
+		{
+			"same unit on two hosts",
+			[]Unit{{Name: "a", Host: "web1", Active: "failed"}, {Name: "a", Host: "web2", Active: "failed"}},
+			UnitHealth{Failed: 2},
+		},
+	}
+	for _, tt := range tests {
+		if got := CountHealth(tt.units); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
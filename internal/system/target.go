@@ -0,0 +1,64 @@
+// package system
+package system
+
+import (
+	"fmt"
+	"strings"
+)
+
+// machinePrefix marks a container target in the string form of a Target.
+const machinePrefix = "machine:"
+
+// Target is where systemctl is pointed: the local machine, a remote host
+// over SSH (systemctl -H) or a local container (systemctl -M).
+// The zero Target is the local machine.
+type Target struct {
+	Host    string // [user@]host[:port], passed as -H
+	Machine string // [user@]container, passed as -M
+}
+
+// ParseTarget parses the string form produced by Target.String:
+// "local" (or ""), "machine:NAME" for a container, anything else is a host.
+func ParseTarget(s string) (Target, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "" || s == "local":
+		return Target{}, nil
+	case strings.HasPrefix(s, machinePrefix):
+		name := strings.TrimPrefix(s, machinePrefix)
+		if name == "" {
+			return Target{}, fmt.Errorf("empty machine name in %q", s)
+		}
+		return Target{Machine: name}, nil
+	case strings.ContainsAny(s, " \t"):
+		return Target{}, fmt.Errorf("invalid host %q", s)
+	}
+	return Target{Host: s}, nil
+}
+
+// IsLocal reports whether t is the local machine.
+func (t Target) IsLocal() bool {
+	return t.Host == "" && t.Machine == ""
+}
+
+// String returns the form accepted by ParseTarget.
+func (t Target) String() string {
+	switch {
+	case t.Host != "":
+		return t.Host
+	case t.Machine != "":
+		return machinePrefix + t.Machine
+	}
+	return "local"
+}
+
+// Flags returns the systemctl transport flags for t, e.g. ["-H", "root@web1"].
+func (t Target) Flags() []string {
+	switch {
+	case t.Host != "":
+		return []string{"-H", t.Host}
+	case t.Machine != "":
+		return []string{"-M", t.Machine}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package system
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Target
+		err  bool
+	}{
+		{"", Target{}, false},
+		{"local", Target{}, false},
+		{" root@web1:2222 ", Target{Host: "root@web1:2222"}, false},
+		{"machine:box", Target{Machine: "box"}, false},
+		{"machine:", Target{}, true},
+		{"web 1", Target{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.s)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseTarget(%q) = %+v, %v; want %+v, error %v", tt.s, got, err, tt.want, tt.err)
+		}
+		if !tt.err && strings.TrimSpace(tt.s) != "" {
+			if back, _ := ParseTarget(got.String()); back != got {
+				t.Errorf("%+v does not round-trip through %q", got, got.String())
+			}
+		}
+	}
+}
+
+// TestTargetFlags checks that the transport flags of the target reach every
+// systemctl and journalctl run against it, right after the scope.
+func TestTargetFlags(t *testing.T) {
+	tests := []struct {
+		opts  Options
+		flags string // joined with spaces, empty for the local system manager
+	}{
+		{Options{}, ""},
+		{Options{Scope: ScopeUser}, "--user "},
+		{Options{Target: Target{Host: "root@web1"}}, "-H root@web1 "},
+		{Options{Scope: ScopeUser, Target: Target{Machine: "box"}}, "--user -M box "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.opts.Target.String()+"/"+tt.opts.Scope.String(), func(t *testing.T) {
+			ex := NewFakeExecutor()
+			ex.Fallback = &FakeResponse{}
+			ctx := context.Background()
+			backend := NewSystemctlBackend(ex, tt.opts)
+			if _, err := backend.ListUnits(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := backend.UnitDetails(ctx, "a.service"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := RecentLogs(ctx, ex, tt.opts, "a.service", 5); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadJournal(ctx, ex, tt.opts, JournalQuery{Unit: "a.service", Limit: 5}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := SystemState(ctx, ex, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			want := []string{
+				"systemctl " + tt.flags + "list-units --all --no-legend --plain --output=json",
+				"systemctl " + tt.flags + "show --no-pager a.service",
+				"journalctl " + tt.flags + "-u a.service -n 5 --no-pager -q -o short",
+				"journalctl " + tt.flags + "-u a.service -o json --no-pager -q -n 5",
+				"systemctl " + tt.flags + "is-system-running",
+			}
+			calls := ex.Calls()
+			if len(calls) != len(want) {
+				t.Fatalf("ran %q, want %d commands", calls, len(want))
+			}
+			for i, c := range calls {
+				if got := strings.Join(c, " "); got != want[i] {
+					t.Errorf("ran %q\nwant %q", got, want[i])
+				}
+			}
+		})
+	}
+}
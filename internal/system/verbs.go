@@ -0,0 +1,118 @@
+// package system
+package system
+
+import "strings"
+
+// Verb describes one systemctl command: what it takes and what it risks.
+// The Commands tab lists Verbs, and CommandSpec is validated against them.
+type Verb struct {
+	Name        string
+	Description string
+	UnitRule    UnitRule
+	Destructive bool     // interrupts a service or throws state away; the preview warns
+	ReadOnly    bool     // only looks at state, so it never needs root
+	Options     []string // verb options it accepts, e.g. --now
+}
+
+// Option is a verb option the preview's option builder can set.
+type Option struct {
+	Name        string
+	Description string
+	Values      []string // choices for --name=value; empty for a plain flag
+}
+
+// options describes every option named in a Verb's Options.
+var options = map[string]Option{
+	"--now":       {Name: "--now", Description: "Also start (or stop) the unit"},
+	"--no-block":  {Name: "--no-block", Description: "Don't wait for the job to finish"},
+	"--force":     {Name: "--force", Description: "Overwrite conflicting symlinks"},
+	"--runtime":   {Name: "--runtime", Description: "Only until the next reboot"},
+	"--signal":    {Name: "--signal", Description: "Signal to send", Values: []string{"SIGTERM", "SIGHUP", "SIGINT", "SIGQUIT", "SIGKILL", "SIGUSR1", "SIGUSR2"}},
+	"--kill-whom": {Name: "--kill-whom", Description: "Processes to signal", Values: []string{"all", "main", "control"}},
+	"--what":      {Name: "--what", Description: "Data to remove", Values: []string{"runtime", "state", "cache", "logs", "configuration", "fdstore", "all"}},
+}
+
+// OptionInfo returns the description of the verb option name.
+func OptionInfo(name string) Option {
+	if o, ok := options[name]; ok {
+		return o
+	}
+	return Option{Name: name}
+}
+
+// Option sets shared by several verbs.
+var (
+	jobOptions  = []string{"--no-block"}
+	fileOptions = []string{"--now", "--runtime", "--force", "--no-block"}
+)
+
+// Verbs is the catalogue offered in the Commands tab, in display order.
+var Verbs = []Verb{
+	{Name: "status", Description: "Show unit status and logs", UnitRule: UnitOptional, ReadOnly: true},
+	{Name: "start", Description: "Start one or more units", UnitRule: UnitRequired, Options: jobOptions},
+	{Name: "stop", Description: "Stop one or more units", UnitRule: UnitRequired, Destructive: true, Options: jobOptions},
+	{Name: "restart", Description: "Restart one or more units", UnitRule: UnitRequired, Destructive: true, Options: jobOptions},
+	{Name: "reload", Description: "Reload the configuration of units", UnitRule: UnitRequired, Options: jobOptions},
+	{Name: "try-restart", Description: "Restart units that are running", UnitRule: UnitRequired, Destructive: true, Options: jobOptions},
+	{Name: "reload-or-restart", Description: "Reload units if supported, restart otherwise", UnitRule: UnitRequired, Destructive: true, Options: jobOptions},
+	{Name: "enable", Description: "Enable one or more units", UnitRule: UnitRequired, Options: fileOptions},
+	{Name: "disable", Description: "Disable one or more units", UnitRule: UnitRequired, Destructive: true, Options: fileOptions},
+	{Name: "mask", Description: "Make units impossible to start", UnitRule: UnitRequired, Destructive: true, Options: fileOptions},
+	{Name: "unmask", Description: "Undo mask", UnitRule: UnitRequired, Options: []string{"--runtime"}},
+	{Name: "kill", Description: "Send a signal to the processes of units", UnitRule: UnitRequired, Destructive: true, Options: []string{"--signal", "--kill-whom"}},
+	{Name: "reset-failed", Description: "Reset the failed state of units (all if none given)", UnitRule: UnitOptional},
+	{Name: "isolate", Description: "Start a unit and stop all others", UnitRule: UnitRequired, Destructive: true, Options: jobOptions},
+	{Name: "cat", Description: "Show the unit files", UnitRule: UnitRequired, ReadOnly: true},
+	{Name: "show", Description: "Show unit properties", UnitRule: UnitOptional, ReadOnly: true},
+	{Name: "list-dependencies", Description: "Show the dependency tree of a unit", UnitRule: UnitOptional, ReadOnly: true},
+	{Name: "freeze", Description: "Suspend the processes of units", UnitRule: UnitRequired, Destructive: true},
+	{Name: "thaw", Description: "Resume frozen units", UnitRule: UnitRequired},
+	{Name: "clean", Description: "Remove runtime, cache, state or log data of units", UnitRule: UnitRequired, Destructive: true, Options: []string{"--what"}},
+	{Name: "preset", Description: "Enable or disable units according to the preset policy", UnitRule: UnitRequired, Options: []string{"--runtime"}},
+	{Name: "revert", Description: "Drop local changes to unit files", UnitRule: UnitRequired, Destructive: true},
+	{Name: "link", Description: "Link a unit file outside the search path (give its path)", UnitRule: UnitRequired, Options: []string{"--runtime", "--force"}},
+}
+
+// hiddenVerbs are run by the UI but not offered in the Commands tab.
+var hiddenVerbs = []Verb{
+	{Name: "--version", UnitRule: UnitNone, ReadOnly: true},
+	{Name: "--help", UnitRule: UnitNone, ReadOnly: true},
+	{Name: "-h", UnitRule: UnitNone, ReadOnly: true},
+	{Name: "is-active", UnitRule: UnitOptional, ReadOnly: true},
+	{Name: "is-enabled", UnitRule: UnitOptional, ReadOnly: true},
+	{Name: "is-failed", UnitRule: UnitOptional, ReadOnly: true},
+	{Name: "list-units", UnitRule: UnitNone, ReadOnly: true},
+	{Name: "list-timers", UnitRule: UnitNone, ReadOnly: true},
+	{Name: "list-sockets", UnitRule: UnitNone, ReadOnly: true},
+	{Name: "list-jobs", UnitRule: UnitNone, ReadOnly: true},
+	{Name: "list-unit-files", UnitRule: UnitNone, ReadOnly: true},
+}
+
+// LookupVerb returns the catalogue entry for name. Unknown verbs are
+// described conservatively: they need a unit and may change state.
+func LookupVerb(name string) (Verb, bool) {
+	for _, list := range [][]Verb{Verbs, hiddenVerbs} {
+		for _, v := range list {
+			if v.Name == name {
+				return v, true
+			}
+		}
+	}
+	return Verb{Name: name, UnitRule: UnitRequired}, false
+}
+
+// AcceptsOption reports whether the verb takes option (e.g. "--now",
+// or "--signal=SIGHUP" by its name). A value must be non-empty and given
+// only to an option that takes one.
+func (v Verb) AcceptsOption(option string) bool {
+	name, value, valued := strings.Cut(option, "=")
+	if valued && (value == "" || len(OptionInfo(name).Values) == 0) {
+		return false
+	}
+	for _, o := range v.Options {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/system"
+)
+
+// batchVerbs are the commands that can be run on all marked units at once.
+var batchVerbs = map[string]bool{
+	"start": true, "stop": true, "restart": true, "enable": true, "disable": true,
+	"reset-failed": true,
+}
+
+// batch is one command run on several units, as one job per unit.
+type batch struct {
+	id     int
+	verb   string
+	units  []system.Unit
+	jobIDs []int // parallel to units
+}
+
+// unitTabs are the tabs listing units, which share the marks.
+var unitTabs = []int{constants.TabUnits, constants.TabFailed}
+
+// toggleMark marks or unmarks the highlighted unit of the active tab and
+// moves to the next one.
+func toggleMark(m model) (model, tea.Cmd) {
+	activeList := &m.lists[m.activeTab]
+	li, ok := activeList.SelectedItem().(listui.ListItem)
+	if !ok {
+		return m, nil
+	}
+
+	key := li.Unit.Key()
+	if m.marked[key] {
+		delete(m.marked, key)
+	} else {
+		m.marked[key] = true
+	}
+	li.Marked = m.marked[key]
+	cmds := []tea.Cmd{activeList.SetItem(activeList.GlobalIndex(), li)}
+	activeList.CursorDown()
+
+	// Show the mark on the unit's row in the other unit tab too
+	for _, tab := range unitTabs {
+		if tab == m.activeTab {
+			continue
+		}
+		l := &m.lists[tab]
+		for i, item := range l.Items() {
+			if other, ok := item.(listui.ListItem); ok && other.Unit.Key() == key {
+				other.Marked = li.Marked
+				cmds = append(cmds, l.SetItem(i, other))
+			}
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// clearMarks unmarks every unit.
+func clearMarks(m model) (model, tea.Cmd) {
+	if len(m.marked) == 0 {
+		return m, nil
+	}
+	m.marked = make(map[string]bool)
+
+	var cmds []tea.Cmd
+	for _, tab := range unitTabs {
+		l := &m.lists[tab]
+		for i, item := range l.Items() {
+			if li, ok := item.(listui.ListItem); ok && li.Marked {
+				li.Marked = false
+				cmds = append(cmds, l.SetItem(i, li))
+			}
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// markedUnits returns the marked units that are still listed, sorted.
+func markedUnits(m model) []system.Unit {
+	var units []system.Unit
+	for _, u := range m.FullUnitList {
+		if m.marked[u.Key()] {
+			units = append(units, u)
+		}
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].Key() < units[j].Key() })
+	return units
+}
+
+// batchSpecs is what a batch runs: verb with the option args on each unit
+// in turn, against the unit's own host.
+func batchSpecs(m model, verb string, args []string, units []system.Unit) []system.CommandSpec {
+	specs := make([]system.CommandSpec, 0, len(units))
+	for _, u := range units {
+		spec := system.NewSystemctlSpec(hostOptions(m, u.Host), verb, u.Name)
+		spec.Args = args
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// batchPreview lists the command lines a batch will run, one per unit, so
+// what is confirmed is exactly what runs.
+func batchPreview(m model, verb string, args []string, units []system.Unit) string {
+	specs := batchSpecs(m, verb, args, units)
+	lines := make([]string, 0, len(specs)+1)
+	for _, spec := range specs {
+		lines = append(lines, spec.String())
+	}
+	lines = append(lines, fmt.Sprintf("(%d units, run as one job each)", len(units)))
+	return strings.Join(lines, "\n")
+}
+
+// submitBatch queues one job per unit and shows the batch summary.
+func submitBatch(m model, verb string, args []string, units []system.Unit) (model, tea.Cmd) {
+	m.nextBatchID++
+	b := batch{id: m.nextBatchID, verb: verb, units: units}
+	for i, spec := range batchSpecs(m, verb, args, units) {
+		backend, ex := hostBackend(m, units[i].Host), m.executor
+		var id int
+		m, id = queueJob(m, spec.String(), func(ctx context.Context, id int) tea.Cmd {
+			return system.StreamSpecAsync(ctx, id, backend, ex, spec)
+		})
+		b.jobIDs = append(b.jobIDs, id)
+	}
+	m.batches = append(m.batches, b)
+
+	m.viewedJob = 0
+	m.viewedBatch = b.id
+	m.previewCommand = fmt.Sprintf("systemctl %s (%d units)", verb, len(units))
+	m.state = StateOutput
+	m, cmd := startQueuedJobs(m)
+	return showBatchSummary(m), cmd
+}
+
+// showBatchSummary refreshes the output screen if it shows a batch.
+func showBatchSummary(m model) model {
+	if m.viewedBatch == 0 || m.state != StateOutput {
+		return m
+	}
+	for _, b := range m.batches {
+		if b.id == m.viewedBatch {
+			m.commandOutput = batchSummary(m, b)
+		}
+	}
+	return m
+}
+
+// batchSummary lists the outcome of every unit in b.
+func batchSummary(m model, b batch) string {
+	counts := make(map[jobStatus]int)
+	var rows []string
+	for i, id := range b.jobIDs {
+		j := m.jobs[findJob(m, id)]
+		counts[j.status]++
+
+		mark := "…"
+		switch j.status {
+		case jobSucceeded:
+			mark = "✓"
+		case jobFailed, jobCancelled, jobTimedOut:
+			mark = "✗"
+		}
+		detail := j.status.String()
+		if j.result != "" {
+			detail = j.result
+		}
+		if output := j.outputText(); mark == "✗" && output != "" {
+			// The last line is usually systemctl's reason
+			last := output[strings.LastIndex(output, "\n")+1:]
+			detail += ": " + strings.TrimPrefix(last, stderrMarker)
+		}
+		label := b.units[i].Name
+		if b.units[i].Host != "" {
+			label = b.units[i].Host + ": " + label
+		}
+		rows = append(rows, fmt.Sprintf("%s #%-4d %-40s %s", mark, j.id, label, detail))
+	}
+
+	failed := counts[jobFailed] + counts[jobCancelled] + counts[jobTimedOut]
+	header := fmt.Sprintf("%s: %d succeeded, %d failed, %d pending", b.verb, counts[jobSucceeded], failed, counts[jobQueued]+counts[jobRunning])
+	return header + "\n\n" + strings.Join(rows, "\n") + "\n\nEach unit's output is in the Jobs tab."
+}
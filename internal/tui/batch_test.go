@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"systemctltui/internal/constants"
+	"systemctltui/internal/system"
+)
+
+// TestBatchPreviewMatchesRun checks that a batch previews, line by line,
+// the commands it then runs: one per marked unit.
+func TestBatchPreviewMatchesRun(t *testing.T) {
+	ex := fixtureExecutor(t)
+	m := startModel(t, ex)
+	names := []string{"plymouth-quit.service", "systemd-resolved.service"}
+	for _, u := range m.FullUnitList {
+		if slices.Contains(names, u.Name) {
+			m.marked[u.Key()] = true
+		}
+	}
+
+	m, _ = send(t, m, key("tab"))
+	m = selectTitle(t, m, constants.TabCommands, "restart")
+	m, _ = send(t, m, key("enter"))
+	if m.state != StatePreview {
+		t.Fatalf("state %v, want the preview", m.state)
+	}
+	lines := strings.Split(m.previewCommand, "\n")
+	want := []string{
+		"systemctl restart -- plymouth-quit.service",
+		"systemctl restart -- systemd-resolved.service",
+		"(2 units, run as one job each)",
+	}
+	if !slices.Equal(lines, want) {
+		t.Fatalf("preview %q\nwant %q", lines, want)
+	}
+
+	for _, name := range names {
+		ex.On(system.FakeResponse{}, "systemctl", "restart", "--", name)
+	}
+	m, _ = send(t, m, key("enter"))
+	var titles []string
+	for _, j := range m.jobs {
+		titles = append(titles, j.title)
+	}
+	if !slices.Equal(titles, want[:2]) {
+		t.Errorf("jobs %q, want the previewed commands", titles)
+	}
+	for _, line := range want[:2] {
+		run := strings.Fields(line)
+		if !slices.ContainsFunc(ex.Calls(), func(call []string) bool { return slices.Equal(call, run) }) {
+			t.Errorf("%q did not run; calls %q", line, ex.Calls())
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
+)
+
+// unitDetailsMsg carries the details of one unit, identified by Unit.Key.
+type unitDetailsMsg struct {
+	key     string
+	details system.UnitDetails
+	err     error
+}
+
+// fetchDetails reads the details of unit in the background, from its own
+// host in the fleet view.
+func fetchDetails(m model, unit system.Unit) tea.Cmd {
+	backend, timeout := hostBackend(m, unit.Host), m.loadTimeout
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		details, err := backend.UnitDetails(ctx, unit.Name)
+		return unitDetailsMsg{key: unit.Key(), details: details, err: err}
+	}
+}
+
+// handleUnitDetails shows fetched details if the output view is still
+// waiting for them.
+func handleUnitDetails(m model, msg unitDetailsMsg) model {
+	if m.state != StateOutput || msg.key != m.detailsKey {
+		return m // the user has moved on
+	}
+	m.detailsKey = ""
+	if msg.err != nil {
+		m.commandOutput = "Failed to load unit details:\n" + msg.err.Error()
+		return m
+	}
+	m.commandOutput = renderDetails(msg.details, time.Now())
+	return m
+}
+
+// renderDetails formats unit details in the spirit of 'systemctl status'.
+func renderDetails(d system.UnitDetails, now time.Time) string {
+	var b strings.Builder
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%12s: %s\n", label, value)
+		}
+	}
+
+	fmt.Fprintf(&b, "%s - %s\n", d.Name, d.Description)
+
+	loaded := d.LoadState
+	if d.FragmentPath != "" {
+		loaded += " (" + d.FragmentPath
+		if d.UnitFileState != "" {
+			loaded += "; " + d.UnitFileState
+		}
+		loaded += ")"
+	}
+	row("Loaded", loaded)
+	row("Drop-In", strings.Join(d.DropInPaths, ", "))
+
+	active := fmt.Sprintf("%s (%s)", d.ActiveState, d.SubState)
+	if !d.StateChangeTimestamp.IsZero() {
+		active += " since " + d.StateChangeTimestamp.Format("Mon 2006-01-02 15:04:05 MST")
+		if up := d.Uptime(now); up > 0 {
+			active += "; " + formatDuration(up) + " ago"
+		}
+	}
+	row("Active", active)
+	if d.Result != "" && d.Result != "success" {
+		row("Result", d.Result)
+	}
+
+	if d.MainPID != 0 {
+		row("Main PID", fmt.Sprint(d.MainPID))
+	}
+	if !d.ExecMainExitTimestamp.IsZero() {
+		row("Last exit", fmt.Sprintf("code=%d, status=%d", d.ExecMainCode, d.ExecMainStatus))
+	}
+	if d.NRestarts > 0 {
+		row("Restarts", fmt.Sprint(d.NRestarts))
+	}
+	if d.TasksCurrent > 0 {
+		row("Tasks", fmt.Sprint(d.TasksCurrent))
+	}
+	if d.MemoryCurrent > 0 {
+		row("Memory", formatBytes(d.MemoryCurrent))
+	}
+	if d.CPUUsageNSec > 0 {
+		row("CPU", formatDuration(time.Duration(d.CPUUsageNSec)))
+	}
+
+	row("Triggers", strings.Join(d.Triggers, ", "))
+	row("TriggeredBy", strings.Join(d.TriggeredBy, ", "))
+	row("Requires", strings.Join(d.Requires, ", "))
+	row("Wants", strings.Join(d.Wants, ", "))
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatBytes renders n with a binary unit suffix, e.g. "12.3M".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatDuration renders d coarsely, e.g. "3d 4h", "2h 5min", "1.234s".
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dmin", d/time.Hour, d%time.Hour/time.Minute)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dmin %ds", d/time.Minute, d%time.Minute/time.Second)
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
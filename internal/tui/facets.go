@@ -0,0 +1,250 @@
+// package tui
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system"
+)
+
+// unitFacets are the unit properties the filter dialog narrows by, in
+// dialog order.
+var unitFacets = []string{"type", "load", "active", "sub"}
+
+// facetValue is u's value for facet.
+func facetValue(u system.Unit, facet string) string {
+	switch facet {
+	case "type":
+		return u.Type
+	case "load":
+		return u.Load
+	case "active":
+		return u.Active
+	default:
+		return u.Sub
+	}
+}
+
+// unitFilter holds the values selected per facet. A unit passes if, in
+// every facet with a selection, its value is one of the selected ones.
+type unitFilter map[string]map[string]bool
+
+// matches reports whether u passes every facet but skip (which may be "").
+func (f unitFilter) matches(u system.Unit, skip string) bool {
+	for facet, values := range f {
+		if facet != skip && len(values) > 0 && !values[facetValue(u, facet)] {
+			return false
+		}
+	}
+	return true
+}
+
+// toggle selects or deselects value in facet.
+func (f unitFilter) toggle(facet, value string) {
+	if f[facet] == nil {
+		f[facet] = make(map[string]bool)
+	}
+	if f[facet][value] {
+		delete(f[facet], value)
+	} else {
+		f[facet][value] = true
+	}
+}
+
+// chips are the active facets, e.g. ["active: failed,inactive"].
+func (f unitFilter) chips() []string {
+	var chips []string
+	for _, facet := range unitFacets {
+		var values []string
+		for v := range f[facet] {
+			values = append(values, v)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		sort.Strings(values)
+		chips = append(chips, facet+": "+strings.Join(values, ","))
+	}
+	return chips
+}
+
+// facetItems lists every value of every facet with the number of units
+// that would show if it were selected too, given the other facets. Values
+// the other facets rule out stay listed with a zero count, so the dialog's
+// rows don't move under the cursor.
+func facetItems(m model) []listui.FacetItem {
+	var items []listui.FacetItem
+	for _, facet := range unitFacets {
+		counts := make(map[string]int)
+		for _, u := range m.FullUnitList {
+			if !fleetVisible(m, u) {
+				continue
+			}
+			v := facetValue(u, facet)
+			if m.unitFilter.matches(u, facet) {
+				counts[v]++
+			} else {
+				counts[v] += 0
+			}
+		}
+		for v := range m.unitFilter[facet] {
+			if _, ok := counts[v]; !ok {
+				counts[v] = 0 // keep selected values visible so they can be cleared
+			}
+		}
+
+		values := make([]string, 0, len(counts))
+		for v := range counts {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		for _, v := range values {
+			items = append(items, listui.FacetItem{Facet: facet, Value: v, Count: counts[v], Selected: m.unitFilter[facet][v]})
+		}
+	}
+	return items
+}
+
+// refreshFacets rebuilds the filter dialog's items, e.g. after the unit
+// list or the selection changed.
+func refreshFacets(m model) (model, tea.Cmd) {
+	cmd := m.filterList.SetItems(listItems(facetItems(m)))
+	return m, tagFacetMatches(cmd)
+}
+
+// facetMatchesMsg carries the results of searching the filter dialog's
+// values. A bare list.FilterMatchesMsg does not say which list it is for,
+// so the dialog's are wrapped to keep them away from the Units list.
+type facetMatchesMsg struct {
+	list.FilterMatchesMsg
+}
+
+// tagFacetMatches wraps the FilterMatchesMsgs cmd (a command returned by
+// filterList) produces as facetMatchesMsgs.
+func tagFacetMatches(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case list.FilterMatchesMsg:
+			return facetMatchesMsg{msg}
+		case tea.BatchMsg:
+			for i := range msg {
+				msg[i] = tagFacetMatches(msg[i])
+			}
+			return msg
+		default:
+			return msg
+		}
+	}
+}
+
+// handleFacetMatches hands search results to the filter dialog, whatever
+// state the UI has moved on to.
+func handleFacetMatches(m model, msg facetMatchesMsg) (model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.filterList, cmd = m.filterList.Update(msg.FilterMatchesMsg)
+	return m, tagFacetMatches(cmd)
+}
+
+func listItems(items []listui.FacetItem) []list.Item {
+	out := make([]list.Item, len(items))
+	for i, it := range items {
+		out[i] = it
+	}
+	return out
+}
+
+// openFilterDialog shows the facet filter dialog over the Units tab.
+func openFilterDialog(m model) (model, tea.Cmd) {
+	m.state = StateFiltering
+	return refreshFacets(m)
+}
+
+// applyUnitFilter re-filters the Units list, keeping the cursor on the same unit.
+func applyUnitFilter(m model) (model, tea.Cmd) {
+	m, listCmd := applyRefresh(m, m.FullUnitList)
+	m, facetCmd := refreshFacets(m)
+	return m, tea.Batch(listCmd, facetCmd)
+}
+
+// updateFiltering handles messages while the filter dialog is shown.
+func updateFiltering(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	// While the user is searching the facet values, every key belongs to the list
+	if _, ok := msg.(tea.KeyMsg); ok && m.filterList.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.filterList, cmd = m.filterList.Update(msg)
+		return m, tagFacetMatches(cmd)
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			if m.filterList.FilterState() != list.Unfiltered {
+				break // let esc clear the search first
+			}
+			m.state = StateBrowse
+			return m, nil
+		case "q", "v":
+			m.state = StateBrowse
+			return m, nil
+		case " ", "enter":
+			if item, ok := m.filterList.SelectedItem().(listui.FacetItem); ok {
+				m.unitFilter.toggle(item.Facet, item.Value)
+				return applyUnitFilter(m)
+			}
+			return m, nil
+		case "c":
+			m.unitFilter = make(unitFilter)
+			return applyUnitFilter(m)
+		}
+
+	case tea.WindowSizeMsg:
+		return setSize(m, msg.Width, msg.Height), nil
+	}
+
+	var cmd tea.Cmd
+	m.filterList, cmd = m.filterList.Update(msg)
+	return m, tagFacetMatches(cmd)
+}
+
+// renderFilterChips shows the active facets above the Units list.
+func renderFilterChips(m model) string {
+	chips := m.unitFilter.chips()
+	if len(chips) == 0 {
+		return ""
+	}
+	parts := make([]string, len(chips))
+	for i, c := range chips {
+		parts[i] = styles.ChipStyle.Render(c)
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(lipgloss.JoinHorizontal(lipgloss.Top, parts...))
+}
+
+// renderFilterView renders the filter dialog.
+func renderFilterView(m model) string {
+	shown := len(m.lists[constants.TabUnits].Items())
+	summary := fmt.Sprintf("Filter units: %d of %d shown", shown, len(m.FullUnitList))
+
+	content := []string{summary}
+	if chips := renderFilterChips(m); chips != "" {
+		content = append(content, chips)
+	}
+	content = append(content, "", m.filterList.View(), "",
+		styles.FooterStyle.UnsetPaddingTop().Render("Space/Enter: toggle | c: clear all | /: search values | Esc/q/v: close"))
+
+	box := styles.AppBoundaryStyle.Render(lipgloss.JoinVertical(lipgloss.Left, content...))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
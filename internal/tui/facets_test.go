@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/system"
+)
+
+var facetTestUnits = []system.Unit{
+	{Name: "a.service", Load: "loaded", Active: "active", Sub: "running", Type: "service"},
+	{Name: "b.socket", Load: "loaded", Active: "failed", Sub: "failed", Type: "socket"},
+	{Name: "c.timer", Load: "loaded", Active: "active", Sub: "waiting", Type: "timer"},
+}
+
+// searchedFilterDialog opens the filter dialog and applies a search of its
+// values, leaving the list in the FilterApplied state.
+func searchedFilterDialog(t *testing.T) model {
+	t.Helper()
+	m := newTestModel(t, system.NewFakeExecutor(), facetTestUnits)
+	m, _ = openFilterDialog(m)
+	m, _ = send(t, m, key("/"), key("f"), key("a"), key("i"), key("l"), key("enter"))
+	if m.state != StateFiltering || m.filterList.FilterState() != list.FilterApplied {
+		t.Fatalf("state %v, filter %v; want the dialog with a search applied", m.state, m.filterList.FilterState())
+	}
+	return m
+}
+
+func TestFilterDialogCloseKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []string
+		closed bool
+		search list.FilterState
+	}{
+		{"q closes", []string{"q"}, true, list.FilterApplied},
+		{"v closes", []string{"v"}, true, list.FilterApplied},
+		{"esc clears the search first", []string{"esc"}, false, list.Unfiltered},
+		{"esc twice closes", []string{"esc", "esc"}, true, list.Unfiltered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := searchedFilterDialog(t)
+			var quit bool
+			for _, k := range tt.keys {
+				var q bool
+				m, q = send(t, m, key(k))
+				quit = quit || q
+			}
+			if quit {
+				t.Fatal("the program quit")
+			}
+			if closed := m.state == StateBrowse; closed != tt.closed {
+				t.Errorf("closed = %v, want %v", closed, tt.closed)
+			}
+			if got := m.filterList.FilterState(); got != tt.search {
+				t.Errorf("search %v, want %v", got, tt.search)
+			}
+		})
+	}
+}
+
+// TestFilterDialogToggle checks that picking a value narrows the Units list.
+func TestFilterDialogToggle(t *testing.T) {
+	m := searchedFilterDialog(t)
+	m, _ = send(t, m, key(" ")) // the only match: active: failed
+	if got := listedNames(m); len(got) != 1 || got[0] != "b.socket" {
+		t.Errorf("listed %q, want only b.socket", got)
+	}
+	m, _ = send(t, m, key("c"))
+	if got := listedNames(m); len(got) != len(facetTestUnits) {
+		t.Errorf("listed %q after clearing, want all units", got)
+	}
+}
+
+// TestFilterKey checks that v opens the filter dialog while f still pages
+// down the Units list, and does not close the dialog either.
+func TestFilterKey(t *testing.T) {
+	var units []system.Unit
+	for i := 0; i < 30; i++ {
+		units = append(units, system.Unit{Name: fmt.Sprintf("u%02d.service", i), Active: "active", Type: "service"})
+	}
+	m := newTestModel(t, system.NewFakeExecutor(), units)
+	m.activeTab = constants.TabUnits
+	// A short terminal, so the units span several pages
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 12})
+	m, _ = setUnits(m, m.FullUnitList)
+
+	m, _ = send(t, m, key("f"))
+	if m.state != StateBrowse {
+		t.Fatalf("state %v after f, want browsing", m.state)
+	}
+	if got := m.lists[constants.TabUnits].Paginator.Page; got != 1 {
+		t.Errorf("page %d after f, want 1", got)
+	}
+
+	m, _ = send(t, m, key("v"))
+	if m.state != StateFiltering {
+		t.Fatalf("state %v after v, want the filter dialog", m.state)
+	}
+	m, _ = send(t, m, key("f"))
+	if m.state != StateFiltering {
+		t.Errorf("f closed the filter dialog")
+	}
+	m, _ = send(t, m, key("v"))
+	if m.state != StateBrowse {
+		t.Errorf("state %v after v in the dialog, want browsing", m.state)
+	}
+}
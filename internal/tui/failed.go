@@ -0,0 +1,105 @@
+// package tui
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/system"
+)
+
+// failedUnits are the units of FullUnitList in the failed state, in list
+// order, whatever the Units tab is filtered to.
+func failedUnits(m model) []system.Unit {
+	var out []system.Unit
+	for _, u := range m.FullUnitList {
+		if u.Active == "failed" {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// refreshFailedList rebuilds the Failed tab from FullUnitList, keeping the
+// cursor on the same unit.
+func refreshFailedList(m model) (model, tea.Cmd) {
+	failedList := &m.lists[constants.TabFailed]
+	var selectedKey string
+	if li, ok := failedList.SelectedItem().(listui.ListItem); ok {
+		selectedKey = li.Unit.Key()
+	}
+
+	failed := failedUnits(m)
+	cmd := failedList.SetItems(listui.UnitItems(failed, m.marked))
+	if failedList.FilterState() == list.Unfiltered {
+		for i, u := range failed {
+			if u.Key() == selectedKey {
+				failedList.Select(i)
+			}
+		}
+	}
+	return m, cmd
+}
+
+// failedTargets are the units a bulk action on the Failed tab applies to:
+// the marked failed units, or all of them if none is marked.
+func failedTargets(m model) []system.Unit {
+	failed := failedUnits(m)
+	var marked []system.Unit
+	for _, u := range failed {
+		if m.marked[u.Key()] {
+			marked = append(marked, u)
+		}
+	}
+	if len(marked) > 0 {
+		return marked
+	}
+	return failed
+}
+
+// previewFailedBatch previews verb on the failed units, to be run as a
+// batch on confirmation like a verb on marked units from the Commands tab.
+func previewFailedBatch(m model, verb string) (model, tea.Cmd) {
+	units := failedTargets(m)
+	if len(units) == 0 {
+		return m, nil
+	}
+	m.selectedCommand = verb
+	m.optionBuilder = newOptionBuilder(verb)
+	m.batchUnits = units
+	m.previewCommand = batchPreview(m, verb, nil, units)
+	m.state = StatePreview
+	return m, nil
+}
+
+// updateFailedKeys handles the keys of the Failed tab. It reports whether
+// it used the key; the rest behave as on the Units tab.
+func updateFailedKeys(m model, msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	li, selected := m.lists[constants.TabFailed].SelectedItem().(listui.ListItem)
+	switch msg.String() {
+	case "R":
+		m, cmd := previewFailedBatch(m, "reset-failed")
+		return m, cmd, true
+	case "r":
+		m, cmd := previewFailedBatch(m, "restart")
+		return m, cmd, true
+	case " ":
+		m, cmd := toggleMark(m)
+		return m, cmd, true
+	case "C":
+		m, cmd := clearMarks(m)
+		return m, cmd, true
+	case "enter", "w":
+		if selected {
+			m, cmd := openTriage(m, li.Unit)
+			return m, cmd, true
+		}
+	case "L":
+		if selected {
+			m, cmd := openLogs(m, li.Unit)
+			return m, cmd, true
+		}
+	}
+	return m, nil, false
+}
@@ -0,0 +1,227 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
+)
+
+// defaultFleetWorkers bounds concurrent fetches when Config leaves it unset.
+const defaultFleetWorkers = 4
+
+// fleetHostMsg carries one target's result of a fleet fetch as soon as it
+// is in; results delivers the rest.
+type fleetHostMsg struct {
+	gen     int
+	initial bool // the fetch loads the fleet view rather than refreshing it
+	result  system.HostResult
+	results <-chan system.HostResult
+}
+
+// fleetDoneMsg ends a fleet fetch once every target answered or timed out.
+type fleetDoneMsg struct {
+	gen     int
+	initial bool
+}
+
+// fetchFleet lists units on every known target in the background, each
+// under its own load timeout. Results arrive one fleetHostMsg per target,
+// followed by a fleetDoneMsg.
+func fetchFleet(m model) tea.Cmd {
+	ex, opts, gen := m.executor, m.options, m.backendGen
+	targets := append([]system.Target(nil), m.knownTargets...)
+	workers, timeout := m.fleetWorkers, m.loadTimeout
+	initial := m.state == StateLoading
+	return func() tea.Msg {
+		results := system.StreamFleet(context.Background(), ex, opts, targets, workers, timeout)
+		return waitForFleetHost(gen, initial, results)()
+	}
+}
+
+// waitForFleetHost reads the next target's result of a fleet fetch.
+func waitForFleetHost(gen int, initial bool, results <-chan system.HostResult) tea.Cmd {
+	return func() tea.Msg {
+		r, ok := <-results
+		if !ok {
+			return fleetDoneMsg{gen: gen, initial: initial}
+		}
+		return fleetHostMsg{gen: gen, initial: initial, result: r, results: results}
+	}
+}
+
+// toggleFleet enters the fleet view, or leaves it for the current target.
+func toggleFleet(m model) (model, tea.Cmd) {
+	if m.fleetMode {
+		// switchBackend leaves fleet mode and restores the cached target view
+		return switchBackend(m, m.options)
+	}
+
+	// Cache the single-target view so leaving the fleet is instant
+	if m.FullUnitList != nil {
+		m.unitCache[cacheKey(m.options)] = m.FullUnitList
+	}
+	m = stopLiveUpdates(m)
+	m.fleetMode = true
+	m.fleetFailedOnly = false
+	m.fleetErrors = nil
+	m.backendGen++ // orphan the single-target ticks and events
+	m.refreshErr = nil
+	m = resetPane(m)
+	m.marked = make(map[string]bool) // marks are per unit list
+	m.systemStates = nil
+	m.selectedUnit, m.selectedHost = "", ""
+	m.loadErr = nil
+	m.state = StateLoading
+	return m, tea.Batch(m.spinner.Tick, fetchFleet(m))
+}
+
+// mergeFleetHost swaps the units of r's target in units for the ones it
+// just reported. A target that failed keeps the units it had, like a
+// failed refresh keeps the list; the status line says it is unreachable.
+func mergeFleetHost(units []system.Unit, r system.HostResult) []system.Unit {
+	if r.Err != nil {
+		return units
+	}
+	host := r.Target.String()
+	others := make([]system.Unit, 0, len(units))
+	for _, u := range units {
+		if u.Host != host {
+			others = append(others, u)
+		}
+	}
+	return system.MergeFleet([]system.HostResult{{Units: others}, r})
+}
+
+// recordFleetError keeps m.fleetErrors to the targets whose latest fetch failed.
+func recordFleetError(m model, r system.HostResult) model {
+	errs := make([]system.HostResult, 0, len(m.fleetErrors)+1)
+	for _, e := range m.fleetErrors {
+		if e.Target != r.Target {
+			errs = append(errs, e)
+		}
+	}
+	if r.Err != nil {
+		errs = append(errs, r)
+	}
+	m.fleetErrors = errs
+	return m
+}
+
+// toggleFleetFailedOnly narrows the fleet view to failed units and back.
+func toggleFleetFailedOnly(m model) (model, tea.Cmd) {
+	m.fleetFailedOnly = !m.fleetFailedOnly
+	return setUnits(m, m.FullUnitList)
+}
+
+// fleetErrorSummary lists the targets that failed, e.g. "web2: exit status 255".
+func fleetErrorSummary(errs []system.HostResult) string {
+	parts := make([]string, len(errs))
+	for i, r := range errs {
+		parts[i] = fmt.Sprintf("%s: %v", r.Target, r.Err)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// fleetStatusLine summarises the fleet view shown above the Units list.
+func fleetStatusLine(m model) string {
+	line := fmt.Sprintf("Fleet: %d targets", len(m.knownTargets))
+	if m.fleetFailedOnly {
+		line += " | failed units only"
+	}
+	if len(m.fleetErrors) > 0 {
+		line += " | unreachable: " + fleetErrorSummary(m.fleetErrors)
+	}
+	return line
+}
+
+// handleFleetHost merges one target's units into the fleet view as they
+// arrive. The loading screen gives way to the list with the first target
+// that answers; the others fill it in without being highlighted.
+func handleFleetHost(m model, msg fleetHostMsg) (model, tea.Cmd) {
+	if msg.gen != m.backendGen {
+		return m, nil // fetched before the view was switched
+	}
+	next := waitForFleetHost(msg.gen, msg.initial, msg.results)
+	m = recordFleetError(m, msg.result)
+	units := mergeFleetHost(m.FullUnitList, msg.result)
+
+	var cmd tea.Cmd
+	switch {
+	case m.state == StateLoading:
+		if msg.result.Err != nil {
+			return m, next // wait for a target that answers
+		}
+		// Whatever was listed before belongs to the single-target view
+		m.loadErr = nil
+		m.state = StateBrowse
+		m, cmd = setUnits(m, mergeFleetHost(nil, msg.result))
+	case msg.initial:
+		m, cmd = replaceUnits(m, units, nil)
+	default:
+		m, cmd = applyRefresh(m, units)
+	}
+	return m, tea.Batch(cmd, next)
+}
+
+// handleFleetDone finishes a fleet fetch. It fails only if no target
+// answered at all: the initial load stays on the loading screen and offers
+// a retry, a refresh keeps the list and shows the error.
+func handleFleetDone(m model, msg fleetDoneMsg) (model, tea.Cmd) {
+	if msg.gen != m.backendGen {
+		return m, nil
+	}
+
+	var err error
+	if len(m.knownTargets) > 0 && len(m.fleetErrors) == len(m.knownTargets) {
+		err = fmt.Errorf("no target responded (%s)", fleetErrorSummary(m.fleetErrors))
+	}
+	var cmd tea.Cmd
+	switch {
+	case m.state == StateLoading && err != nil:
+		m.loadErr = err
+		return m, nil
+	case m.state == StateLoading:
+		// No targets at all
+		m.state = StateBrowse
+		m, cmd = setUnits(m, nil)
+	default:
+		m.refreshErr = err
+	}
+	return m, tea.Batch(cmd, scheduleRefresh(m))
+}
+
+// actionOptions are the options commands on the selected unit run with: the
+// unit's own host in the fleet view, the current target otherwise.
+func actionOptions(m model) system.Options {
+	return hostOptions(m, m.selectedHost)
+}
+
+// hostOptions are m.options pointed at host (a Unit.Host), or m.options
+// themselves for units that don't carry a host.
+func hostOptions(m model, host string) system.Options {
+	opts := m.options
+	if host != "" {
+		if target, err := system.ParseTarget(host); err == nil {
+			opts.Target = target
+		}
+	}
+	return opts
+}
+
+// actionBackend is the backend for commands on the selected unit.
+func actionBackend(m model) system.Backend {
+	return hostBackend(m, m.selectedHost)
+}
+
+// hostBackend is a systemctl backend for host in the fleet view, or the
+// current backend for units that don't carry a host.
+func hostBackend(m model, host string) system.Backend {
+	if opts := hostOptions(m, host); opts != m.options {
+		return system.NewSystemctlBackend(m.executor, opts)
+	}
+	return m.backend
+}
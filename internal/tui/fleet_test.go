@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
+)
+
+// hangingExecutor is a FakeExecutor whose commands for the hosts in hang
+// never finish before their context is done, like an unreachable SSH target.
+type hangingExecutor struct {
+	*system.FakeExecutor
+	hang map[string]bool
+}
+
+func (h hangingExecutor) RunContext(ctx context.Context, name string, args ...string) (system.Result, error) {
+	if len(args) > 1 && args[0] == "-H" && h.hang[args[1]] {
+		<-ctx.Done()
+		return system.Result{}, ctx.Err()
+	}
+	return h.FakeExecutor.RunContext(ctx, name, args...)
+}
+
+// fleetMsgs runs a fleet fetch to its end, returning its messages in the
+// order they arrive.
+func fleetMsgs(t *testing.T, m model) []tea.Msg {
+	t.Helper()
+	msgs := []tea.Msg{fetchFleet(m)()}
+	for {
+		host, ok := msgs[len(msgs)-1].(fleetHostMsg)
+		if !ok {
+			return msgs
+		}
+		msgs = append(msgs, waitForFleetHost(host.gen, host.initial, host.results)())
+	}
+}
+
+// TestFleetPerHost checks that the fleet view fills in as hosts answer: the
+// first answer ends the loading screen, a hung host only holds up itself,
+// and unreachable hosts are reported while the others' units merge.
+func TestFleetPerHost(t *testing.T) {
+	fake := system.NewFakeExecutor()
+	fake.Fallback = &system.FakeResponse{Stdout: "running\n"} // is-system-running
+	list := []string{"list-units", "--all", "--no-legend", "--plain", "--output=json"}
+	fake.On(system.FakeResponse{Stdout: "sshd.service loaded active running OpenSSH\n"}, "systemctl", list...)
+	fake.On(system.FakeResponse{Stdout: "nginx.service loaded failed failed Web\nsshd.service loaded active running OpenSSH\n"}, "systemctl", append([]string{"-H", "web1"}, list...)...)
+	fake.On(system.FakeResponse{Stderr: "ssh: timeout\n", ExitCode: 255}, "systemctl", append([]string{"-H", "web1"}, list...)...) // on the refresh
+	fake.On(system.FakeResponse{Stderr: "ssh: connect to host down: No route to host\n", ExitCode: 255}, "systemctl", append([]string{"-H", "down"}, list...)...)
+	ex := hangingExecutor{FakeExecutor: fake, hang: map[string]bool{"hung": true}}
+
+	m := NewModel(ex, system.NewSystemctlBackend(ex, system.Options{}), Config{
+		Targets:      []system.Target{{Host: "hung"}, {Host: "web1"}, {Host: "down"}},
+		FleetWorkers: 4,
+		LoadTimeout:  200 * time.Millisecond,
+	})
+	m = setSize(m, 120, 40)
+	m.state = StateBrowse
+	m, _ = toggleFleet(m)
+	if m.state != StateLoading {
+		t.Fatalf("state %v, want loading", m.state)
+	}
+
+	start := time.Now()
+	msgs := fleetMsgs(t, m)
+	if len(msgs) != 5 {
+		t.Fatalf("%d messages, want one per target and done", len(msgs))
+	}
+	for i, msg := range msgs[:4] {
+		r := msg.(fleetHostMsg).result
+		if r.Target.Host == "hung" && i != 3 {
+			t.Errorf("the hung host answered %d. of 4", i+1)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fetch took %s with a 200ms timeout per host", elapsed)
+	}
+
+	for _, msg := range msgs {
+		next, _ := m.update(msg)
+		m = next.(model)
+		if _, done := msg.(fleetDoneMsg); !done {
+			if r := msg.(fleetHostMsg).result; r.Err == nil && m.state != StateBrowse {
+				t.Fatalf("still loading after %s answered", r.Target)
+			}
+		}
+	}
+
+	want := []string{"nginx.service", "sshd.service", "sshd.service"}
+	if got := listedNames(m); !slices.Equal(got, want) {
+		t.Errorf("listed %q, want %q", got, want)
+	}
+	if got := m.FullUnitList[1].Host; got != "local" {
+		t.Errorf("sshd.service of %q sorted first, want local", got)
+	}
+	line := fleetStatusLine(m)
+	for _, host := range []string{"hung: ", "down: failed to execute systemctl: exit status 255"} {
+		if !strings.Contains(line, host) {
+			t.Errorf("status line %q does not report %q", line, host)
+		}
+	}
+	if m.refreshErr != nil {
+		t.Errorf("refresh error %v with two hosts answering", m.refreshErr)
+	}
+
+	// A refresh where web1 stops answering keeps its units and reports it
+	for _, msg := range fleetMsgs(t, m) {
+		next, _ := m.update(msg)
+		m = next.(model)
+	}
+	if got := listedNames(m); !slices.Equal(got, want) {
+		t.Errorf("after web1 failed: listed %q, want %q", got, want)
+	}
+	if line := fleetStatusLine(m); !strings.Contains(line, "web1: failed to execute systemctl: exit status 255") {
+		t.Errorf("status line %q does not report web1", line)
+	}
+}
+
+// TestFleetNoTargetAnswers keeps the loading screen with a retry when every
+// target fails.
+func TestFleetNoTargetAnswers(t *testing.T) {
+	ex := system.NewFakeExecutor()
+	ex.Fallback = &system.FakeResponse{Stderr: "Failed to connect to bus\n", ExitCode: 1}
+	m := NewModel(ex, system.NewSystemctlBackend(ex, system.Options{}), Config{Targets: []system.Target{{Host: "web1"}}})
+	m.state = StateBrowse
+	m, _ = toggleFleet(m)
+
+	for _, msg := range fleetMsgs(t, m) {
+		next, _ := m.update(msg)
+		m = next.(model)
+	}
+	if m.state != StateLoading || m.loadErr == nil || !strings.Contains(m.loadErr.Error(), "no target responded") {
+		t.Errorf("state %v, error %v; want the loading screen with an error", m.state, m.loadErr)
+	}
+}
+
+// TestFleetSystemStates checks that a slow host only makes its own manager
+// state unknown in the health header.
+func TestFleetSystemStates(t *testing.T) {
+	fake := system.NewFakeExecutor()
+	fake.On(system.FakeResponse{Stdout: "running\n"}, "systemctl", "is-system-running")
+	fake.On(system.FakeResponse{Stdout: "degraded\n", ExitCode: 1}, "systemctl", "-H", "web1", "is-system-running")
+	ex := hangingExecutor{FakeExecutor: fake, hang: map[string]bool{"hung": true}}
+
+	m := NewModel(ex, system.NewSystemctlBackend(ex, system.Options{}), Config{
+		Targets:      []system.Target{{Host: "hung"}, {Host: "web1"}},
+		FleetWorkers: 1, // web1 is only asked once hung has timed out
+		LoadTimeout:  50 * time.Millisecond,
+	})
+	m.fleetMode = true
+
+	msg := fetchSystemStates(m)().(systemStatesMsg)
+	want := map[string]string{"local": "running", "hung": "unknown", "web1": "degraded"}
+	if !maps.Equal(msg.states, want) {
+		t.Errorf("states %v, want %v", msg.states, want)
+	}
+	m = handleSystemStates(m, msg)
+	if header := renderHealthHeader(m); !strings.Contains(header, "1 degraded, 1 running, 1 unknown") {
+		t.Errorf("header %q", header)
+	}
+}
@@ -0,0 +1,150 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
+)
+
+// logFollowMax caps the entries kept while following; the oldest are
+// dropped (and can be paged back in with "o").
+const logFollowMax = 5000
+
+// logFollowMsg carries one line of 'journalctl -f'.
+type logFollowMsg struct {
+	seq   int
+	entry system.JournalEntry
+	err   error // the line could not be used, or why following ended
+	done  bool  // journalctl exited
+	next  tea.Cmd
+}
+
+// startFollow runs 'journalctl -f' from just after the newest entry shown.
+func startFollow(m model) (model, tea.Cmd) {
+	m = stopFollow(m)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.logs.stopFollow = cancel
+	m.logs.following = true
+	m.logs.followSeq++
+
+	q := m.logs.query
+	q.Limit, q.Before = 0, ""
+	if n := len(m.logs.entries); n > 0 {
+		q.After = m.logs.entries[n-1].Cursor
+	}
+	ex, opts, seq := m.executor, hostOptions(m, m.logs.unit.Host), m.logs.followSeq
+	return m, func() tea.Msg {
+		s, err := system.FollowJournal(ctx, ex, opts, q)
+		if err != nil {
+			return logFollowMsg{seq: seq, done: true, err: err}
+		}
+		return readFollow(seq, s)()
+	}
+}
+
+// stopFollow kills the running 'journalctl -f', if any, and shows what
+// arrived while paused.
+func stopFollow(m model) model {
+	if m.logs.stopFollow != nil {
+		m.logs.stopFollow()
+		m.logs.stopFollow = nil
+	}
+	m.logs.following = false
+	m.logs.followSeq++ // lines still in flight are drained and dropped
+	if m.logs.paused {
+		m.logs.paused = false
+		m = appendLogEntries(m, m.logs.pending)
+		m.logs.pending = nil
+	}
+	return m
+}
+
+// togglePause holds new entries back while following, or shows them.
+func togglePause(m model) model {
+	if !m.logs.following {
+		return m
+	}
+	m.logs.paused = !m.logs.paused
+	if !m.logs.paused {
+		m = appendLogEntries(m, m.logs.pending)
+		m.logs.pending = nil
+	}
+	return m
+}
+
+// readFollow returns a command reading the next line of s.
+func readFollow(seq int, s system.Stream) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-s.Lines()
+		if !ok {
+			return logFollowMsg{seq: seq, done: true, err: s.Wait()}
+		}
+		if line.Stderr {
+			return logFollowMsg{seq: seq, err: errors.New(line.Text), next: readFollow(seq, s)}
+		}
+		entry, err := system.ParseJournalJSON([]byte(line.Text))
+		return logFollowMsg{seq: seq, entry: entry, err: err, next: readFollow(seq, s)}
+	}
+}
+
+// handleLogFollow adds a followed entry to the Logs screen. Lines of a
+// follow that has since been stopped are drained so journalctl never
+// blocks on a full pipe before it is killed.
+func handleLogFollow(m model, msg logFollowMsg) (model, tea.Cmd) {
+	if msg.seq != m.logs.followSeq {
+		return m, msg.next
+	}
+	switch {
+	case msg.done:
+		m.logs.stopFollow = nil
+		m = stopFollow(m)
+		if msg.err != nil {
+			m.logs.err = fmt.Errorf("following stopped: %w", msg.err)
+		}
+		return m, nil
+	case msg.err != nil:
+		m.logs.err = msg.err
+	case m.logs.paused:
+		// Held back to the same cap as shown entries: once the pending ones
+		// alone fill the screen, what was shown before them goes anyway
+		m.logs.pending = append(m.logs.pending, msg.entry)
+		if drop := len(m.logs.pending) - logFollowMax; drop > 0 {
+			// Re-slicing is enough: append moves them to a new array when it grows
+			m.logs.pending = m.logs.pending[drop:]
+			m.logs.older = true
+		}
+	default:
+		m = appendLogEntries(m, []system.JournalEntry{msg.entry})
+	}
+	return m, msg.next
+}
+
+// appendLogEntries adds entries at the bottom of the Logs screen, keeping
+// the newest line in view unless the user has scrolled up.
+func appendLogEntries(m model, entries []system.JournalEntry) model {
+	if len(entries) == 0 {
+		return m
+	}
+	follow := m.logs.view.AtBottom()
+	offset := m.logs.view.YOffset
+
+	m.logs.entries = append(m.logs.entries, entries...)
+	if drop := len(m.logs.entries) - logFollowMax; drop > 0 {
+		offset -= strings.Count(renderLogLines(m.logs.entries[:drop]), "\n") + 1
+		m.logs.entries = append([]system.JournalEntry(nil), m.logs.entries[drop:]...)
+		m.logs.older = true
+	}
+
+	m.logs.view.SetContent(renderLogLines(m.logs.entries))
+	if follow {
+		m.logs.view.GotoBottom()
+	} else {
+		m.logs.view.SetYOffset(max(0, offset))
+	}
+	return m
+}
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"strconv"
+	"testing"
+
+	"systemctltui/internal/system"
+)
+
+// TestPausedFollowCap checks that entries held back while following is
+// paused are capped like the shown ones, dropping the oldest.
+func TestPausedFollowCap(t *testing.T) {
+	m := newTestModel(t, system.NewFakeExecutor(), nil)
+	m.logs.entries = []system.JournalEntry{{Message: "shown"}}
+	m.logs.following, m.logs.paused = true, true
+
+	const extra = 10
+	for i := range logFollowMax + extra {
+		m, _ = handleLogFollow(m, logFollowMsg{seq: m.logs.followSeq, entry: system.JournalEntry{Message: strconv.Itoa(i)}})
+	}
+	if n := len(m.logs.pending); n != logFollowMax {
+		t.Fatalf("%d entries pending, want %d", n, logFollowMax)
+	}
+	if got := m.logs.pending[0].Message; got != strconv.Itoa(extra) {
+		t.Errorf("oldest pending entry %s, want %d", got, extra)
+	}
+	if !m.logs.older {
+		t.Error("dropped entries not marked as older history")
+	}
+
+	m = togglePause(m)
+	if n := len(m.logs.entries); n != logFollowMax || m.logs.entries[0].Message != strconv.Itoa(extra) {
+		t.Errorf("%d entries shown from %q after resuming", n, m.logs.entries[0].Message)
+	}
+	if last := m.logs.entries[logFollowMax-1].Message; last != strconv.Itoa(logFollowMax+extra-1) {
+		t.Errorf("newest entry %s", last)
+	}
+}
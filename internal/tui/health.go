@@ -0,0 +1,105 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system"
+)
+
+// systemStatesMsg carries 'systemctl is-system-running' per Unit.Host.
+type systemStatesMsg struct {
+	gen    int
+	states map[string]string
+}
+
+// unitsUpdated keeps what is derived from FullUnitList in step after it
+// changed: the Failed tab always, and the manager state when the change
+// may have moved it (every full refresh, and live events that changed the
+// number of failed units).
+func unitsUpdated(m model, refetchState bool) (model, tea.Cmd) {
+	m, cmd := refreshFailedList(m)
+	if !refetchState {
+		return m, cmd
+	}
+	return m, tea.Batch(cmd, fetchSystemStates(m))
+}
+
+// fetchSystemStates asks every listed host for its manager state: each
+// target in the fleet view, the current one otherwise. Targets are asked
+// like the fleet is fetched, concurrently and each under its own timeout,
+// so one slow host does not leave the others unknown.
+func fetchSystemStates(m model) tea.Cmd {
+	targets, hosts := []system.Target{m.options.Target}, []string{""}
+	if m.fleetMode {
+		targets = append([]system.Target(nil), m.knownTargets...)
+		hosts = make([]string, len(targets))
+		for i, t := range targets {
+			hosts[i] = t.String()
+		}
+	}
+
+	ex, opts, gen := m.executor, m.options, m.backendGen
+	workers, timeout := m.fleetWorkers, m.loadTimeout
+	return func() tea.Msg {
+		states := system.FetchStates(context.Background(), ex, opts, targets, workers, timeout)
+		byHost := make(map[string]string, len(hosts))
+		for i, host := range hosts {
+			byHost[host] = states[i]
+		}
+		return systemStatesMsg{gen: gen, states: byHost}
+	}
+}
+
+// handleSystemStates stores fetched manager states for the header.
+func handleSystemStates(m model, msg systemStatesMsg) model {
+	if msg.gen != m.backendGen {
+		return m // asked before the backend was switched
+	}
+	m.systemStates = msg.states
+	return m
+}
+
+// renderHealthHeader is the line above the tabs summing up the manager:
+// its state and how many units are running, failed and activating.
+func renderHealthHeader(m model) string {
+	h := system.CountHealth(m.FullUnitList)
+
+	var state string
+	switch {
+	case len(m.systemStates) == 0:
+		state = styles.SystemStateStyle("").Render("checking...")
+	case !m.fleetMode:
+		s := m.systemStates[""]
+		state = styles.SystemStateStyle(s).Render(s)
+	default:
+		// e.g. "3 running, 1 degraded" across the fleet
+		counts := make(map[string]int)
+		for _, s := range m.systemStates {
+			counts[s]++
+		}
+		names := make([]string, 0, len(counts))
+		for s := range counts {
+			names = append(names, s)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for i, s := range names {
+			parts[i] = styles.SystemStateStyle(s).Render(fmt.Sprintf("%d %s", counts[s], s))
+		}
+		state = strings.Join(parts, ", ")
+	}
+
+	failed := fmt.Sprintf("%d failed", h.Failed)
+	if h.Failed > 0 {
+		failed = styles.WarningStyle.Render(failed)
+	}
+	line := fmt.Sprintf("System: %s | Units: %d running, %s, %d activating", state, h.Running, failed, h.Activating)
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+}
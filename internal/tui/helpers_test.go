@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
+)
+
+// newTestModel is a browsing model over units, run against a fake executor.
+func newTestModel(t *testing.T, ex *system.FakeExecutor, units []system.Unit) model {
+	t.Helper()
+	m := NewModel(ex, system.NewSystemctlBackend(ex, system.Options{}), Config{})
+	m = setSize(m, 120, 40)
+	m.state = StateBrowse
+	m, _ = setUnits(m, units)
+	return m
+}
+
+// key builds the KeyMsg bubbletea sends for a key name as msg.String()
+// reports it, e.g. "q", "esc", "enter".
+func key(name string) tea.KeyMsg {
+	switch name {
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "shift+tab":
+		return tea.KeyMsg{Type: tea.KeyShiftTab}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// cmdWait is how long send waits for a command's message. Commands still
+// running after that (ticks, polling) are dropped.
+const cmdWait = 50 * time.Millisecond
+
+// send feeds msgs to m through Update one at a time, each followed, like in
+// the bubbletea runtime, by the messages of the commands it returns until
+// none is left. It reports whether any of them asked to quit.
+func send(t *testing.T, m model, msgs ...tea.Msg) (model, bool) {
+	t.Helper()
+	quit := false
+	for _, msg := range msgs {
+		pending := []tea.Msg{msg}
+		for n := 0; len(pending) > 0; n++ {
+			if n == 1000 {
+				t.Fatalf("messages keep coming: %T", pending[0])
+			}
+			msg := pending[0]
+			pending = pending[1:]
+			switch msg := msg.(type) {
+			case tea.QuitMsg:
+				quit = true
+				continue
+			case spinner.TickMsg:
+				continue // spins forever
+			case tea.BatchMsg:
+				for _, cmd := range msg {
+					pending = append(pending, runCmd(cmd)...)
+				}
+				continue
+			}
+			next, cmd := m.Update(msg)
+			m = next.(model)
+			pending = append(pending, runCmd(cmd)...)
+		}
+	}
+	return m, quit
+}
+
+// runCmd runs cmd, returning its message unless it takes longer than cmdWait.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		if msg == nil {
+			return nil
+		}
+		return []tea.Msg{msg}
+	case <-time.After(cmdWait):
+		return nil
+	}
+}
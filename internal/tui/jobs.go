@@ -0,0 +1,397 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/messages"
+	"systemctltui/internal/system"
+)
+
+const (
+	// defaultMaxJobs bounds concurrently running jobs when Config leaves it unset.
+	defaultMaxJobs = 4
+	// managerJobsInterval is how often systemd's job queue is re-read while
+	// the Jobs tab is open.
+	managerJobsInterval = 2 * time.Second
+	// stderrMarker prefixes stderr lines in job output.
+	stderrMarker = "[stderr] "
+)
+
+// jobStatus is where a job is in its lifecycle.
+type jobStatus int
+
+const (
+	jobQueued jobStatus = iota // waiting for a free slot
+	jobRunning
+	jobSucceeded
+	jobFailed
+	jobCancelled
+	jobTimedOut
+)
+
+func (s jobStatus) String() string {
+	switch s {
+	case jobQueued:
+		return "queued"
+	case jobRunning:
+		return "running"
+	case jobSucceeded:
+		return "done"
+	case jobFailed:
+		return "failed"
+	case jobCancelled:
+		return "cancelled"
+	default:
+		return "timed out"
+	}
+}
+
+// job is one command run from the UI, in the background or on the output screen.
+type job struct {
+	id      int
+	title   string // the command line, as previewed
+	status  jobStatus
+	started time.Time        // zero while queued
+	ended   time.Time        // zero until finished
+	output  *strings.Builder // streamed output so far, stderr lines marked; nil until the first line
+	result  string           // how it ended, e.g. "exit status 3 after 1.2s"
+
+	run    func(ctx context.Context, id int) tea.Cmd
+	cancel context.CancelFunc // set while running
+}
+
+// appendOutput adds a line to the job's output. A builder keeps long,
+// chatty output from being copied again for every line.
+func (j *job) appendOutput(line string) {
+	if j.output == nil {
+		j.output = new(strings.Builder)
+	}
+	if j.output.Len() > 0 {
+		j.output.WriteByte('\n')
+	}
+	j.output.WriteString(line)
+}
+
+// outputText is what the job printed so far.
+func (j job) outputText() string {
+	if j.output == nil {
+		return ""
+	}
+	return j.output.String()
+}
+
+// Title implements list.DefaultItem.
+func (j job) Title() string { return fmt.Sprintf("#%d %s", j.id, j.title) }
+
+// Description implements list.DefaultItem.
+func (j job) Description() string {
+	switch j.status {
+	case jobQueued:
+		return "queued"
+	case jobRunning:
+		return fmt.Sprintf("running %s (since %s)", time.Since(j.started).Round(time.Second), j.started.Format("15:04:05"))
+	default:
+		return fmt.Sprintf("%s: %s (at %s)", j.status, j.result, j.ended.Format("15:04:05"))
+	}
+}
+
+// FilterValue implements list.Item.
+func (j job) FilterValue() string { return j.title }
+
+// jobsTickMsg redraws running times once a second while jobs run.
+type jobsTickMsg struct{}
+
+// managerJobsTickMsg re-reads systemd's job queue while the Jobs tab is open.
+type managerJobsTickMsg struct{ seq int }
+
+// managerJobsMsg carries systemd's job queue.
+type managerJobsMsg struct {
+	seq  int
+	poll bool // from the polling loop, which goes on; false for a one-off refresh
+	jobs []system.ManagerJob
+	err  error
+}
+
+// submitJob queues a command as a new job and shows its output screen.
+// run receives the context the command must be killed with (cancelled by
+// the user or after m.commandTimeout) and the ID its messages are tagged with.
+func submitJob(m model, title string, run func(ctx context.Context, id int) tea.Cmd) (model, tea.Cmd) {
+	m, id := queueJob(m, title, run)
+	m = openJob(m, id)
+	return startQueuedJobs(m)
+}
+
+// queueJob adds a job to the queue without starting or showing it.
+func queueJob(m model, title string, run func(ctx context.Context, id int) tea.Cmd) (model, int) {
+	m.nextJobID++
+	m.jobs = append(m.jobs, job{id: m.nextJobID, title: title, status: jobQueued, run: run})
+	return m, m.nextJobID
+}
+
+// startQueuedJobs starts queued jobs, oldest first, while slots are free.
+func startQueuedJobs(m model) (model, tea.Cmd) {
+	running := 0
+	for _, j := range m.jobs {
+		if j.status == jobRunning {
+			running++
+		}
+	}
+
+	var cmds []tea.Cmd
+	for i := range m.jobs {
+		if running >= m.maxJobs {
+			break
+		}
+		if m.jobs[i].status != jobQueued {
+			continue
+		}
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+		if m.commandTimeout > 0 {
+			ctx, cancel = context.WithTimeout(context.Background(), m.commandTimeout)
+		} else {
+			ctx, cancel = context.WithCancel(context.Background())
+		}
+		j := &m.jobs[i]
+		j.status, j.started, j.cancel = jobRunning, time.Now(), cancel
+		cmds = append(cmds, j.run(ctx, j.id))
+		running++
+	}
+
+	if running > 0 && !m.jobsTicking {
+		m.jobsTicking = true
+		cmds = append(cmds, jobsTick())
+	}
+	m, listCmd := refreshJobsList(m)
+	return m, tea.Batch(append(cmds, listCmd)...)
+}
+
+func jobsTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return jobsTickMsg{} })
+}
+
+// handleJobsTick keeps ticking while anything runs.
+func handleJobsTick(m model) (model, tea.Cmd) {
+	for _, j := range m.jobs {
+		if j.status == jobRunning {
+			var cmd tea.Cmd
+			m, cmd = refreshJobsList(m) // running times
+			return m, tea.Batch(cmd, jobsTick())
+		}
+	}
+	m.jobsTicking = false
+	return m, nil
+}
+
+// findJob returns the index of job id in m.jobs, or -1.
+func findJob(m model, id int) int {
+	for i, j := range m.jobs {
+		if j.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// openJob shows the output screen of job id.
+func openJob(m model, id int) model {
+	i := findJob(m, id)
+	if i < 0 {
+		return m
+	}
+	m.viewedJob, m.viewedBatch = id, 0
+	m.previewCommand = m.jobs[i].title
+	m.commandOutput = m.jobs[i].outputText()
+	m.state = StateOutput
+	return m
+}
+
+// viewedJobRunning reports whether the output screen shows a running job.
+func viewedJobRunning(m model) bool {
+	i := findJob(m, m.viewedJob)
+	return i >= 0 && m.jobs[i].status == jobRunning
+}
+
+// cancelJob kills a running job, or drops a queued one.
+func cancelJob(m model, id int) (model, tea.Cmd) {
+	i := findJob(m, id)
+	if i < 0 {
+		return m, nil
+	}
+	switch j := &m.jobs[i]; j.status {
+	case jobRunning:
+		j.cancel() // its CommandFinishedMsg reports it as cancelled
+	case jobQueued:
+		j.status, j.ended, j.result = jobCancelled, time.Now(), "never started"
+	}
+	return refreshJobsList(m)
+}
+
+// handleCommandOutput appends a streamed line to its job. The pager is
+// only updated (and so re-rendered) for the job on the output screen.
+func handleCommandOutput(m model, msg messages.CommandOutputMsg) (model, tea.Cmd) {
+	if i := findJob(m, msg.ID); i >= 0 {
+		line := msg.Line
+		if msg.Stderr {
+			line = stderrMarker + line
+		}
+		j := &m.jobs[i]
+		j.appendOutput(line)
+		if m.viewedJob == j.id && m.state == StateOutput {
+			m.commandOutput = j.outputText()
+		}
+	}
+	// Keep reading until the command finishes so it never blocks
+	return m, msg.Next
+}
+
+// handleJobFinished records how a job ended and starts the next queued one.
+func handleJobFinished(m model, msg messages.CommandFinishedMsg) (model, tea.Cmd) {
+	i := findJob(m, msg.ID)
+	if i < 0 {
+		return m, nil
+	}
+	j := &m.jobs[i]
+	j.cancel() // release the timeout
+	j.cancel = nil
+	j.ended = time.Now()
+	j.result = jobResult(msg)
+
+	var exitErr *system.ExitError
+	switch {
+	case msg.Status == messages.CommandCancelled:
+		j.status = jobCancelled
+	case msg.Status == messages.CommandTimedOut:
+		j.status = jobTimedOut
+	case msg.Err == nil:
+		j.status = jobSucceeded
+	default:
+		j.status = jobFailed
+		if !errors.As(msg.Err, &exitErr) {
+			// The command could not run at all; say why in the output itself
+			j.appendOutput("--- ERROR ---\n" + msg.Err.Error())
+		}
+	}
+	if m.viewedJob == j.id && m.state == StateOutput {
+		m.commandOutput = j.outputText()
+	}
+
+	m, cmd := startQueuedJobs(m)
+	m = showBatchSummary(m)
+	// systemd's queue has likely changed too
+	return m, tea.Batch(cmd, fetchManagerJobs(m, false))
+}
+
+// jobResult summarises a finished command, e.g. "exit status 3 after 1.2s".
+func jobResult(msg messages.CommandFinishedMsg) string {
+	elapsed := msg.Elapsed.Round(100 * time.Millisecond)
+	var exitErr *system.ExitError
+	switch {
+	case msg.Status != messages.CommandCompleted:
+		return fmt.Sprintf("%s after %s", msg.Status, elapsed)
+	case msg.Err == nil:
+		return fmt.Sprintf("exit status 0 after %s", elapsed)
+	case errors.As(msg.Err, &exitErr):
+		return fmt.Sprintf("exit status %d after %s", msg.ExitCode, elapsed)
+	default:
+		return fmt.Sprintf("failed after %s", elapsed)
+	}
+}
+
+// commandStatus describes the viewed job for the output footer.
+func commandStatus(m model) string {
+	i := findJob(m, m.viewedJob)
+	if i < 0 {
+		return ""
+	}
+	j := m.jobs[i]
+	switch j.status {
+	case jobQueued:
+		return fmt.Sprintf("queued (all %d job slots busy)", m.maxJobs)
+	case jobRunning:
+		return fmt.Sprintf("running %s", time.Since(j.started).Round(time.Second))
+	default:
+		return j.result
+	}
+}
+
+// refreshJobsList rebuilds the Jobs tab, newest job first, keeping the
+// cursor on the same job.
+func refreshJobsList(m model) (model, tea.Cmd) {
+	jobsList := &m.lists[constants.TabJobs]
+	selected := -1
+	if j, ok := jobsList.SelectedItem().(job); ok {
+		selected = j.id
+	}
+
+	items := make([]list.Item, 0, len(m.jobs))
+	index := 0
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		if m.jobs[i].id == selected {
+			index = len(items)
+		}
+		items = append(items, m.jobs[i])
+	}
+	cmd := jobsList.SetItems(items)
+	jobsList.Select(index)
+	return m, cmd
+}
+
+// fetchManagerJobs reads systemd's job queue in the background. With poll
+// it is a step of the polling loop and schedules the next one; otherwise it
+// only refreshes the queue, leaving the loop (if any) to its own timer.
+func fetchManagerJobs(m model, poll bool) tea.Cmd {
+	backend, seq, timeout := m.backend, m.managerJobsSeq, m.loadTimeout
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		jobs, err := backend.ListJobs(ctx)
+		return managerJobsMsg{seq: seq, poll: poll, jobs: jobs, err: err}
+	}
+}
+
+// watchManagerJobs starts polling systemd's job queue, replacing any
+// earlier polling loop. It is called when the Jobs tab is opened.
+func watchManagerJobs(m model) (model, tea.Cmd) {
+	m.managerJobsSeq++
+	return m, fetchManagerJobs(m, true)
+}
+
+// handleManagerJobs stores systemd's job queue and polls again while the
+// Jobs tab is still open.
+func handleManagerJobs(m model, msg managerJobsMsg) (model, tea.Cmd) {
+	if msg.seq != m.managerJobsSeq {
+		return m, nil // from an older polling loop
+	}
+	m.managerJobs, m.managerJobsErr = msg.jobs, msg.err
+	if !msg.poll || m.activeTab != constants.TabJobs {
+		return m, nil // stop polling; reopening the tab restarts it
+	}
+	seq := msg.seq
+	return m, tea.Tick(managerJobsInterval, func(time.Time) tea.Msg { return managerJobsTickMsg{seq: seq} })
+}
+
+// managerJobsSection renders systemd's job queue below the Jobs list.
+func managerJobsSection(m model) string {
+	if m.managerJobsErr != nil {
+		return "systemd job queue: " + m.managerJobsErr.Error()
+	}
+	if len(m.managerJobs) == 0 {
+		return "systemd job queue: empty"
+	}
+	lines := []string{fmt.Sprintf("systemd job queue (%d):", len(m.managerJobs))}
+	for _, j := range m.managerJobs {
+		lines = append(lines, fmt.Sprintf("  %d %s %s (%s)", j.ID, j.Unit, j.Type, j.State))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/messages"
+	"systemctltui/internal/system"
+)
+
+// stuckExecutor streams commands that print nothing and never exit on their
+// own, like a 'systemctl stop' waiting on a hung unit.
+type stuckExecutor struct {
+	*system.FakeExecutor
+}
+
+func (stuckExecutor) Stream(ctx context.Context, name string, args ...string) (system.Stream, error) {
+	s := &stuckStream{ctx: ctx, lines: make(chan system.Line)}
+	go func() {
+		<-ctx.Done()
+		close(s.lines)
+	}()
+	return s, nil
+}
+
+type stuckStream struct {
+	ctx   context.Context
+	lines chan system.Line
+}
+
+func (s *stuckStream) Lines() <-chan system.Line { return s.lines }
+func (s *stuckStream) Wait() error               { return s.ctx.Err() }
+
+// submitStuckJob runs 'systemctl stop a.service' as a job that only ends
+// when its context does. The job's final message arrives on the channel.
+func submitStuckJob(t *testing.T, cfg Config) (model, <-chan tea.Msg) {
+	t.Helper()
+	ex := stuckExecutor{system.NewFakeExecutor()}
+	backend := system.NewSystemctlBackend(ex, system.Options{})
+	m := NewModel(ex, backend, cfg)
+	m = setSize(m, 120, 40)
+	m.state = StateBrowse
+
+	finished := make(chan tea.Msg, 1)
+	spec := system.NewSystemctlSpec(system.Options{}, "stop", "a.service")
+	m, _ = submitJob(m, spec.String(), func(ctx context.Context, id int) tea.Cmd {
+		run := system.StreamSpecAsync(ctx, id, backend, ex, spec)
+		go func() { finished <- run() }()
+		return nil
+	})
+	if m.state != StateOutput || !viewedJobRunning(m) {
+		t.Fatalf("state %v; want the output of the running job", m.state)
+	}
+	return m, finished
+}
+
+// waitFinished feeds the job's final message to m.
+func waitFinished(t *testing.T, m model, finished <-chan tea.Msg) model {
+	t.Helper()
+	select {
+	case msg := <-finished:
+		m, _ = send(t, m, msg)
+		return m
+	case <-time.After(2 * time.Second):
+		t.Fatal("the job did not end")
+	}
+	return m
+}
+
+func TestCancelJobFromOutput(t *testing.T) {
+	for _, k := range []string{"x", "ctrl+c"} {
+		t.Run(k, func(t *testing.T) {
+			m, finished := submitStuckJob(t, Config{})
+			m, quit := send(t, m, key(k))
+			if quit {
+				t.Fatal("quit instead of cancelling the job")
+			}
+			m = waitFinished(t, m, finished)
+
+			j := m.jobs[0]
+			if j.status != jobCancelled {
+				t.Errorf("job %s, want cancelled", j.status)
+			}
+			if !strings.HasPrefix(j.result, "cancelled after") {
+				t.Errorf("result %q", j.result)
+			}
+			if m.state != StateOutput {
+				t.Errorf("state %v, want the output to stay open", m.state)
+			}
+			// Nothing left to cancel: ctrl+c quits again, x does nothing
+			if _, quit := send(t, m, key(k)); quit != (k == "ctrl+c") {
+				t.Errorf("%s after the job ended: quit = %v", k, quit)
+			}
+		})
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	m, finished := submitStuckJob(t, Config{CommandTimeout: 50 * time.Millisecond})
+	m = waitFinished(t, m, finished)
+
+	j := m.jobs[0]
+	if j.status != jobTimedOut {
+		t.Errorf("job %s, want timed out", j.status)
+	}
+	if !strings.HasPrefix(j.result, "timed out after") || strings.Contains(j.outputText(), "--- ERROR ---") {
+		t.Errorf("result %q, output %q", j.result, j.outputText())
+	}
+	if !strings.HasPrefix(commandStatus(m), "timed out after") {
+		t.Errorf("output footer says %q", commandStatus(m))
+	}
+}
+
+// TestJobOutput checks that streamed lines collect in their job, and that
+// only the job on the output screen reaches the pager.
+func TestJobOutput(t *testing.T) {
+	m := newTestModel(t, system.NewFakeExecutor(), nil)
+	noop := func(context.Context, int) tea.Cmd { return nil }
+	m, viewed := queueJob(m, "systemctl status a.service", noop)
+	m, other := queueJob(m, "systemctl status b.service", noop)
+	m = openJob(m, viewed)
+
+	var want []string
+	for i := range 500 {
+		line := fmt.Sprintf("line %d", i)
+		m, _ = send(t, m,
+			messages.CommandOutputMsg{ID: viewed, Line: line},
+			messages.CommandOutputMsg{ID: other, Line: line, Stderr: i%2 == 1},
+		)
+		want = append(want, line)
+	}
+
+	if got := m.jobs[0].outputText(); got != strings.Join(want, "\n") {
+		t.Errorf("viewed job output has %d lines, want %d", strings.Count(got, "\n")+1, len(want))
+	}
+	if m.commandOutput != m.jobs[0].outputText() || m.pager.source != m.commandOutput {
+		t.Error("the pager does not show the viewed job's output")
+	}
+	otherOut := m.jobs[1].outputText()
+	if !strings.HasPrefix(otherOut, "line 0\n"+stderrMarker+"line 1\n") || strings.Count(otherOut, "\n") != 499 {
+		t.Errorf("other job output starts %q", otherOut[:min(len(otherOut), 40)])
+	}
+
+	// Switching jobs shows the other output in full
+	m, _ = send(t, m, key("esc"))
+	m = openJob(m, other)
+	if m.commandOutput != otherOut {
+		t.Error("opening the other job does not show its output")
+	}
+}
@@ -0,0 +1,151 @@
+// package tui
+package tui
+
+import (
+	"context"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/system"
+)
+
+// subscribedMsg is sent once the backend starts pushing unit events.
+// Like every live/refresh message it carries the backendGen it was started
+// under, so messages from a backend that has since been replaced are dropped.
+type subscribedMsg struct {
+	gen    int
+	events <-chan system.UnitEvent
+}
+
+// subscriptionFailedMsg is sent when live updates could not be started
+// or the event stream ended.
+type subscriptionFailedMsg struct {
+	gen int
+	err error
+}
+
+// unitEventMsg carries one incremental change to the unit list. It holds the
+// event channel so the listener can be re-armed after handling it.
+type unitEventMsg struct {
+	gen    int
+	event  system.UnitEvent
+	events <-chan system.UnitEvent
+}
+
+// startLiveUpdates subscribes to unit events if the backend supports them.
+func startLiveUpdates(m model) (model, tea.Cmd) {
+	m = stopLiveUpdates(m)
+	sub, ok := m.backend.(system.Subscriber)
+	if !ok {
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stopLive = cancel
+	gen := m.backendGen
+	return m, func() tea.Msg {
+		events, err := sub.Subscribe(ctx)
+		if err != nil {
+			return subscriptionFailedMsg{gen: gen, err: err}
+		}
+		return subscribedMsg{gen: gen, events: events}
+	}
+}
+
+// stopLiveUpdates ends the current subscription, if any.
+func stopLiveUpdates(m model) model {
+	if m.stopLive != nil {
+		m.stopLive()
+		m.stopLive = nil
+	}
+	m.liveUpdates = false
+	return m
+}
+
+// waitForUnitEvent blocks until the next event arrives on events.
+func waitForUnitEvent(gen int, events <-chan system.UnitEvent) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-events
+		if !ok {
+			return subscriptionFailedMsg{gen: gen}
+		}
+		return unitEventMsg{gen: gen, event: ev, events: events}
+	}
+}
+
+// applyUnitEvent updates FullUnitList and the Units tab list in place, so the
+// cursor and any active filter survive the change.
+func applyUnitEvent(m model, ev system.UnitEvent) (model, tea.Cmd) {
+	name := ev.Unit.Name
+
+	// Keep the full list in sync first
+	fullIdx := -1
+	for i, u := range m.FullUnitList {
+		if u.Name == name {
+			fullIdx = i
+			break
+		}
+	}
+	switch ev.Kind {
+	case system.UnitAdded:
+		if fullIdx >= 0 {
+			m.FullUnitList[fullIdx] = ev.Unit
+		} else {
+			m.FullUnitList = append(m.FullUnitList, ev.Unit)
+		}
+	case system.UnitRemoved:
+		if fullIdx >= 0 {
+			m.FullUnitList = append(m.FullUnitList[:fullIdx], m.FullUnitList[fullIdx+1:]...)
+		}
+	case system.UnitChanged:
+		if fullIdx < 0 {
+			return m, nil // a unit we never listed; the next UnitNew will bring it in
+		}
+		m.FullUnitList[fullIdx] = ev.Apply(m.FullUnitList[fullIdx])
+		ev.Unit = m.FullUnitList[fullIdx]
+	}
+
+	// Then patch the visible list item
+	unitsList := &m.lists[constants.TabUnits]
+	items := unitsList.Items()
+	listIdx, insertAt := -1, len(items)
+	for i, item := range items {
+		li, ok := item.(listui.ListItem)
+		if !ok {
+			continue
+		}
+		if li.Unit.Name == name {
+			listIdx = i
+			break
+		}
+		if insertAt == len(items) && li.Unit.Name > name {
+			insertAt = i // keep the list sorted by name
+		}
+	}
+
+	// A unit that no longer passes the filter dialog's facets leaves the list
+	visible := ev.Kind != system.UnitRemoved && unitVisible(m, ev.Unit)
+	// The list does not move its cursor when items come or go above it
+	cursor, unfiltered := unitsList.Index(), unitsList.FilterState() == list.Unfiltered
+	switch {
+	case !visible && listIdx >= 0:
+		unitsList.RemoveItem(listIdx)
+		if unfiltered && listIdx < cursor {
+			unitsList.Select(cursor - 1)
+		}
+		return m, nil
+	case !visible:
+		return m, nil
+	case listIdx >= 0:
+		return m, unitsList.SetItem(listIdx, listui.ListItem{Unit: ev.Unit, Marked: m.marked[ev.Unit.Key()]})
+	case ev.Kind == system.UnitAdded:
+		cmd := unitsList.InsertItem(insertAt, listui.ListItem{Unit: ev.Unit, Marked: m.marked[ev.Unit.Key()]})
+		if unfiltered && insertAt <= cursor && len(items) > 0 {
+			unitsList.Select(cursor + 1)
+		}
+		return m, cmd
+	}
+	return m, nil
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"systemctltui/internal/constants"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/system"
+)
+
+// listedNames are the units shown in the Units tab, in order.
+func listedNames(m model) []string {
+	var names []string
+	for _, item := range m.lists[constants.TabUnits].Items() {
+		names = append(names, item.(listui.ListItem).Unit.Name)
+	}
+	return names
+}
+
+func unitNamed(units []system.Unit, name string) (system.Unit, bool) {
+	for _, u := range units {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return system.Unit{}, false
+}
+
+func TestApplyUnitEvent(t *testing.T) {
+	base := []system.Unit{
+		{Name: "a.service", Load: "loaded", Active: "active", Sub: "running", Description: "A", Type: "service"},
+		{Name: "c.service", Load: "loaded", Active: "active", Sub: "running", Description: "C", Type: "service"},
+		{Name: "e.socket", Load: "loaded", Active: "active", Sub: "listening", Description: "E", Type: "socket"},
+	}
+
+	tests := []struct {
+		name   string
+		filter unitFilter
+		event  system.UnitEvent
+		listed []string
+		want   *system.Unit // FullUnitList entry for the event's unit; nil if absent
+	}{
+		{
+			name:   "changed merges the delta",
+			event:  system.UnitEvent{Kind: system.UnitChanged, Unit: system.Unit{Name: "c.service", Active: "failed", Sub: "failed"}},
+			listed: []string{"a.service", "c.service", "e.socket"},
+			want:   &system.Unit{Name: "c.service", Load: "loaded", Active: "failed", Sub: "failed", Description: "C", Type: "service"},
+		},
+		{
+			name:   "changed unknown unit is ignored",
+			event:  system.UnitEvent{Kind: system.UnitChanged, Unit: system.Unit{Name: "x.service", Active: "failed"}},
+			listed: []string{"a.service", "c.service", "e.socket"},
+		},
+		{
+			name:   "added is inserted in name order",
+			event:  system.UnitEvent{Kind: system.UnitAdded, Unit: system.Unit{Name: "b.timer", Load: "loaded", Active: "active", Sub: "waiting", Type: "timer"}},
+			listed: []string{"a.service", "b.timer", "c.service", "e.socket"},
+			want:   &system.Unit{Name: "b.timer", Load: "loaded", Active: "active", Sub: "waiting", Type: "timer"},
+		},
+		{
+			name:   "added last",
+			event:  system.UnitEvent{Kind: system.UnitAdded, Unit: system.Unit{Name: "z.path", Active: "active", Type: "path"}},
+			listed: []string{"a.service", "c.service", "e.socket", "z.path"},
+			want:   &system.Unit{Name: "z.path", Active: "active", Type: "path"},
+		},
+		{
+			name:   "added again replaces",
+			event:  system.UnitEvent{Kind: system.UnitAdded, Unit: system.Unit{Name: "a.service", Load: "loaded", Active: "inactive", Sub: "dead", Description: "A2", Type: "service"}},
+			listed: []string{"a.service", "c.service", "e.socket"},
+			want:   &system.Unit{Name: "a.service", Load: "loaded", Active: "inactive", Sub: "dead", Description: "A2", Type: "service"},
+		},
+		{
+			name:   "removed",
+			event:  system.UnitEvent{Kind: system.UnitRemoved, Unit: system.Unit{Name: "c.service"}},
+			listed: []string{"a.service", "e.socket"},
+		},
+		{
+			name:   "changed out of the facet filter",
+			filter: unitFilter{"active": {"active": true}},
+			event:  system.UnitEvent{Kind: system.UnitChanged, Unit: system.Unit{Name: "a.service", Active: "failed", Sub: "failed"}},
+			listed: []string{"c.service", "e.socket"},
+			want:   &system.Unit{Name: "a.service", Load: "loaded", Active: "failed", Sub: "failed", Description: "A", Type: "service"},
+		},
+		{
+			name:   "added outside the facet filter",
+			filter: unitFilter{"type": {"service": true}},
+			event:  system.UnitEvent{Kind: system.UnitAdded, Unit: system.Unit{Name: "b.timer", Type: "timer"}},
+			listed: []string{"a.service", "c.service"},
+			want:   &system.Unit{Name: "b.timer", Type: "timer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(system.NewFakeExecutor(), nil, Config{})
+			if tt.filter != nil {
+				m.unitFilter = tt.filter
+			}
+			m, _ = setUnits(m, append([]system.Unit(nil), base...))
+
+			m, _ = applyUnitEvent(m, tt.event)
+
+			if got := listedNames(m); !slices.Equal(got, tt.listed) {
+				t.Errorf("listed %q, want %q", got, tt.listed)
+			}
+			got, ok := unitNamed(m.FullUnitList, tt.event.Unit.Name)
+			switch {
+			case tt.want == nil && ok:
+				t.Errorf("FullUnitList still has %+v", got)
+			case tt.want != nil && got != *tt.want:
+				t.Errorf("FullUnitList has %+v, want %+v", got, *tt.want)
+			}
+			for _, item := range m.lists[constants.TabUnits].Items() {
+				li := item.(listui.ListItem)
+				if full, _ := unitNamed(m.FullUnitList, li.Unit.Name); full != li.Unit {
+					t.Errorf("list item %+v out of step with %+v", li.Unit, full)
+				}
+			}
+		})
+	}
+}
+
+// TestApplyUnitEventKeepsCursor checks that the selection stays on its unit
+// when others are inserted or removed above it.
+func TestApplyUnitEventKeepsCursor(t *testing.T) {
+	m := newTestModel(t, system.NewFakeExecutor(), []system.Unit{
+		{Name: "a.service", Type: "service"},
+		{Name: "c.service", Type: "service"},
+	})
+	m.lists[constants.TabUnits].Select(1)
+	selected := func() string {
+		li, _ := m.lists[constants.TabUnits].SelectedItem().(listui.ListItem)
+		return li.Unit.Name
+	}
+
+	events := []system.UnitEvent{
+		{Kind: system.UnitAdded, Unit: system.Unit{Name: "b.service", Type: "service"}},
+		{Kind: system.UnitRemoved, Unit: system.Unit{Name: "a.service"}},
+		{Kind: system.UnitAdded, Unit: system.Unit{Name: "d.service", Type: "service"}},
+	}
+	for _, ev := range events {
+		m, _ = applyUnitEvent(m, ev)
+		if got := selected(); got != "c.service" {
+			t.Fatalf("after %+v: cursor on %q, want c.service", ev, got)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system"
+)
+
+// Rows of the log filter bar, in display order.
+const (
+	filterPriority = iota
+	filterBoot
+	filterSince
+	filterUntil
+	filterGrep
+	filterRows
+)
+
+// logFilterBarHeight is the height the bar takes from the log viewport: a
+// line per row, a blank line and the keys.
+const logFilterBarHeight = filterRows + 2
+
+// logFilterBar is the form on the Logs screen that narrows the journal
+// query. It is only applied (and the log reloaded) on Enter; until then the
+// command line shows what would run.
+type logFilterBar struct {
+	editing  bool
+	row      int
+	priority int // 0 for any, otherwise 1 + index into system.JournalPriorities
+	boot     int // 0 for all boots, otherwise 1 + index into boots
+	boots    []system.Boot
+	bootsErr error
+	inputs   [filterRows]textinput.Model // for the since, until and grep rows
+}
+
+// bootsLoadedMsg carries the boots of a host's journal.
+type bootsLoadedMsg struct {
+	host  string
+	boots []system.Boot
+	err   error
+}
+
+func newLogFilterBar() logFilterBar {
+	var bar logFilterBar
+	placeholders := map[int]string{
+		filterSince: "e.g. -1h, today, 2024-01-02 10:00",
+		filterUntil: "e.g. -10min, now",
+		filterGrep:  "regular expression",
+	}
+	for row, placeholder := range placeholders {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.Placeholder = placeholder
+		ti.CharLimit = 256
+		ti.Width = 40 // placeholders are cut to the width
+		bar.inputs[row] = ti
+	}
+	return bar
+}
+
+// query is q with the bar's filters in place of its own.
+func (b logFilterBar) query(q system.JournalQuery) system.JournalQuery {
+	q.Priority, q.Boot = "", ""
+	if b.priority > 0 {
+		q.Priority = system.JournalPriorities[b.priority-1]
+	}
+	if b.boot > 0 {
+		q.Boot = strconv.Itoa(b.boots[b.boot-1].Offset)
+	}
+	q.Since = b.inputs[filterSince].Value()
+	q.Until = b.inputs[filterUntil].Value()
+	q.Grep = b.inputs[filterGrep].Value()
+	return q
+}
+
+// reset sets the bar back to the filters of q, dropping unapplied edits.
+func (b logFilterBar) reset(q system.JournalQuery) logFilterBar {
+	b.priority = 0
+	for i, name := range system.JournalPriorities {
+		if name == q.Priority {
+			b.priority = i + 1
+		}
+	}
+	b.boot = 0
+	for i, boot := range b.boots {
+		if strconv.Itoa(boot.Offset) == q.Boot {
+			b.boot = i + 1
+		}
+	}
+	b.inputs[filterSince].SetValue(q.Since)
+	b.inputs[filterUntil].SetValue(q.Until)
+	b.inputs[filterGrep].SetValue(q.Grep)
+	return b
+}
+
+// focus moves the bar to row, focusing its text input if it has one.
+func (b logFilterBar) focus(row int) (logFilterBar, tea.Cmd) {
+	b.row = (row + filterRows) % filterRows
+	var cmd tea.Cmd
+	for i := range b.inputs {
+		switch {
+		case i == b.row && i >= filterSince:
+			cmd = b.inputs[i].Focus()
+		default:
+			b.inputs[i].Blur()
+		}
+	}
+	return b, cmd
+}
+
+// cycle moves the priority or boot row by delta through its choices.
+func (b logFilterBar) cycle(delta int) logFilterBar {
+	switch b.row {
+	case filterPriority:
+		n := len(system.JournalPriorities) + 1
+		b.priority = ((b.priority+delta)%n + n) % n
+	case filterBoot:
+		n := len(b.boots) + 1
+		b.boot = ((b.boot+delta)%n + n) % n
+	}
+	return b
+}
+
+// openLogFilter shows the filter bar, loading the host's boots the first time.
+func openLogFilter(m model) (model, tea.Cmd) {
+	m.logs.filter.editing = true
+	m.logs.filter = m.logs.filter.reset(m.logs.query)
+	m = sizeLogView(m)
+
+	var focus tea.Cmd
+	m.logs.filter, focus = m.logs.filter.focus(m.logs.filter.row)
+	if m.logs.filter.boots != nil {
+		return m, focus
+	}
+
+	ex, opts, host, timeout := m.executor, hostOptions(m, m.logs.unit.Host), m.logs.unit.Host, m.loadTimeout
+	return m, tea.Batch(focus, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		boots, err := system.ListBoots(ctx, ex, opts)
+		return bootsLoadedMsg{host: host, boots: boots, err: err}
+	})
+}
+
+// closeLogFilter hides the filter bar.
+func closeLogFilter(m model) model {
+	m.logs.filter.editing = false
+	for i := range m.logs.filter.inputs {
+		m.logs.filter.inputs[i].Blur()
+	}
+	atBottom := m.logs.view.AtBottom()
+	m = sizeLogView(m)
+	if atBottom {
+		m.logs.view.GotoBottom()
+	}
+	return m
+}
+
+// handleBootsLoaded fills the boot row of the filter bar, newest boot first.
+func handleBootsLoaded(m model, msg bootsLoadedMsg) model {
+	if msg.host != m.logs.unit.Host {
+		return m // the Logs screen moved to another host since
+	}
+	boots := make([]system.Boot, 0, len(msg.boots)) // non-nil: loaded, so don't ask again
+	for i := len(msg.boots) - 1; i >= 0; i-- {
+		boots = append(boots, msg.boots[i])
+	}
+	m.logs.filter.boots, m.logs.filter.bootsErr = boots, msg.err
+	for i, boot := range boots {
+		if strconv.Itoa(boot.Offset) == m.logs.query.Boot {
+			m.logs.filter.boot = i + 1
+		}
+	}
+	return m
+}
+
+// updateLogFilter handles keys while the filter bar has focus.
+func updateLogFilter(m model, msg tea.KeyMsg) (model, tea.Cmd) {
+	bar := m.logs.filter
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "ctrl+c":
+		return stopFollow(m), tea.Quit
+	case "esc":
+		m.logs.filter = bar.reset(m.logs.query)
+		return closeLogFilter(m), nil
+	case "enter":
+		// Apply: the newest page of the filtered journal
+		m.logs.query = bar.query(m.logs.query)
+		m = closeLogFilter(m)
+		return reloadLogs(m)
+	case "ctrl+u":
+		m.logs.filter = bar.reset(system.JournalQuery{})
+		return m, nil
+	case "tab", "down":
+		m.logs.filter, cmd = bar.focus(bar.row + 1)
+		return m, cmd
+	case "shift+tab", "up":
+		m.logs.filter, cmd = bar.focus(bar.row - 1)
+		return m, cmd
+	}
+
+	if bar.row < filterSince {
+		switch msg.String() {
+		case "right", "l", " ":
+			m.logs.filter = bar.cycle(1)
+		case "left", "h":
+			m.logs.filter = bar.cycle(-1)
+		}
+		return m, nil
+	}
+	m.logs.filter.inputs[bar.row], cmd = bar.inputs[bar.row].Update(msg)
+	return m, cmd
+}
+
+// clearLogFilters drops every filter and reloads.
+func clearLogFilters(m model) (model, tea.Cmd) {
+	q := &m.logs.query
+	q.Priority, q.Since, q.Until, q.Boot, q.Grep = "", "", "", "", ""
+	m.logs.filter = m.logs.filter.reset(m.logs.query)
+	return reloadLogs(m)
+}
+
+// logsFiltered reports whether the applied query has any filter.
+func logsFiltered(q system.JournalQuery) bool {
+	return q.Priority != "" || q.Since != "" || q.Until != "" || q.Boot != "" || q.Grep != ""
+}
+
+// renderLogFilter renders the filter bar, one row per filter.
+func renderLogFilter(m model) string {
+	bar := m.logs.filter
+
+	priority := "any"
+	if bar.priority > 0 {
+		priority = system.JournalPriorities[bar.priority-1] + " and more urgent"
+	}
+	boot := "all boots"
+	switch {
+	case bar.boot > 0:
+		b := bar.boots[bar.boot-1]
+		boot = fmt.Sprintf("%d  %s", b.Offset, b.Span)
+	case bar.bootsErr != nil:
+		boot += " (" + bar.bootsErr.Error() + ")"
+	case bar.boots == nil:
+		boot += " (loading boots...)"
+	}
+
+	rows := []string{
+		filterPriority: "Priority: ◂ " + priority + " ▸",
+		filterBoot:     "Boot:     ◂ " + boot + " ▸",
+		filterSince:    "Since:    " + bar.inputs[filterSince].View(),
+		filterUntil:    "Until:    " + bar.inputs[filterUntil].View(),
+		filterGrep:     "Grep:     " + bar.inputs[filterGrep].View(),
+	}
+	width := m.width - pagerChromeWidth
+	for i, row := range rows {
+		if i == bar.row {
+			rows[i] = styles.SearchCurrentStyle.MaxWidth(width).Render("> " + row)
+		} else {
+			rows[i] = lipgloss.NewStyle().MaxWidth(width).Render("  " + row)
+		}
+	}
+	keys := "Tab/↑/↓: field | ←/→: change | Enter: apply | Ctrl+U: clear all | Esc: cancel"
+	rows = append(rows, "", styles.FooterStyle.UnsetPaddingTop().MaxWidth(width).Render(keys))
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
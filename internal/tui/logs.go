@@ -0,0 +1,281 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system"
+)
+
+// logPageSize is how many journal entries are read at a time, both at first
+// and for every page back through history.
+const logPageSize = 200
+
+// logView is the state of the Logs screen (StateLogs) for one unit.
+type logView struct {
+	unit    system.Unit
+	query   system.JournalQuery // the query of the newest page; Before is unset
+	entries []system.JournalEntry
+	view    viewport.Model
+	seq     int // bumped for every reload; results of older loads are dropped
+	loading bool
+	older   bool // history before entries[0] may exist
+	err     error
+
+	// Follow mode (journalctl -f), see follow.go
+	following  bool
+	paused     bool // new entries wait in pending
+	pending    []system.JournalEntry
+	followSeq  int                // bumped whenever a follow is stopped
+	stopFollow context.CancelFunc // kills the running journalctl -f
+
+	filter logFilterBar // see logfilter.go
+}
+
+// logsLoadedMsg carries a page of journal entries.
+type logsLoadedMsg struct {
+	seq     int
+	entries []system.JournalEntry
+	older   bool // a page from before the first entry shown
+	err     error
+}
+
+// openLogs shows the Logs screen for unit and loads its newest entries.
+// A follow still running for the previous unit is stopped.
+func openLogs(m model, unit system.Unit) (model, tea.Cmd) {
+	m = stopFollow(m)
+	view := viewport.New(0, 0)
+	view.SetHorizontalStep(pagerHorizontalStep)
+	m.logs = logView{
+		unit:      unit,
+		query:     system.JournalQuery{Unit: unit.Name, Limit: logPageSize},
+		view:      view,
+		filter:    newLogFilterBar(),
+		seq:       m.logs.seq + 1,
+		followSeq: m.logs.followSeq,
+	}
+	m.state = StateLogs
+	m = sizeLogView(m)
+	return reloadLogs(m)
+}
+
+// reloadLogs reads the newest page of the unit's journal again, ending
+// follow mode.
+func reloadLogs(m model) (model, tea.Cmd) {
+	m = stopFollow(m)
+	m.logs.seq++
+	m.logs.loading = true
+	return m, fetchLogs(m, m.logs.query, false)
+}
+
+// loadOlderLogs reads the page of history before the first entry shown.
+func loadOlderLogs(m model) (model, tea.Cmd) {
+	if m.logs.loading || !m.logs.older || len(m.logs.entries) == 0 {
+		return m, nil
+	}
+	q := m.logs.query
+	q.Before = m.logs.entries[0].Cursor
+	m.logs.loading = true
+	return m, fetchLogs(m, q, true)
+}
+
+// fetchLogs runs q in the background against the unit's own host.
+func fetchLogs(m model, q system.JournalQuery, older bool) tea.Cmd {
+	ex, opts, seq, timeout := m.executor, hostOptions(m, m.logs.unit.Host), m.logs.seq, m.loadTimeout
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		entries, err := system.ReadJournal(ctx, ex, opts, q)
+		return logsLoadedMsg{seq: seq, entries: entries, older: older, err: err}
+	}
+}
+
+// handleLogsLoaded adds a page of entries to the Logs screen. The newest
+// page starts scrolled to the bottom; older pages are put above the view
+// without moving it.
+func handleLogsLoaded(m model, msg logsLoadedMsg) model {
+	if msg.seq != m.logs.seq {
+		return m // the screen was closed or reloaded since
+	}
+	m.logs.loading = false
+	m.logs.err = msg.err
+	if msg.err != nil {
+		return m
+	}
+
+	// A short page means we reached the start of the journal
+	m.logs.older = m.logs.query.Limit > 0 && len(msg.entries) >= m.logs.query.Limit
+	if msg.older {
+		added := strings.Count(renderLogLines(msg.entries), "\n") + 1
+		if len(msg.entries) == 0 {
+			added = 0
+		}
+		m.logs.entries = append(msg.entries, m.logs.entries...)
+		offset := m.logs.view.YOffset + added
+		m.logs.view.SetContent(renderLogLines(m.logs.entries))
+		m.logs.view.SetYOffset(offset)
+		return m
+	}
+	m.logs.entries = msg.entries
+	m.logs.view.SetContent(renderLogLines(m.logs.entries))
+	m.logs.view.GotoBottom()
+	return m
+}
+
+// sizeLogView fits the log viewport into the terminal, like the output pager.
+func sizeLogView(m model) model {
+	m.logs.view.Width = max(0, m.width-pagerChromeWidth)
+	m.logs.view.Height = max(0, m.height-pagerChromeHeight)
+	if m.logs.filter.editing {
+		m.logs.view.Height = max(0, m.logs.view.Height-logFilterBarHeight)
+	}
+	return m
+}
+
+// updateLogs handles messages while the Logs screen is shown.
+func updateLogs(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.logs.filter.editing {
+			return updateLogFilter(m, msg)
+		}
+		switch msg.String() {
+		case "ctrl+c":
+			return stopFollow(m), tea.Quit
+		case "esc", "q":
+			m = stopFollow(m)
+			m.logs.seq++ // drop loads still in flight
+			m.state = StateBrowse
+			return m, nil
+		case "F":
+			// Like less: follow new entries as they are written
+			if m.logs.following {
+				return stopFollow(m), nil
+			}
+			if m.logs.loading {
+				return m, nil // wait for the page to follow on from
+			}
+			return startFollow(m)
+		case "p":
+			return togglePause(m), nil
+		case "/":
+			return openLogFilter(m)
+		case "c":
+			if !logsFiltered(m.logs.query) {
+				return m, nil
+			}
+			return clearLogFilters(m)
+		case "o":
+			return loadOlderLogs(m)
+		case "r":
+			return reloadLogs(m)
+		case "g", "home":
+			m.logs.view.GotoTop()
+			return m, nil
+		case "G", "end":
+			m.logs.view.GotoBottom()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.logs.view, cmd = m.logs.view.Update(msg)
+		return m, cmd
+
+	case tea.WindowSizeMsg:
+		m = setSize(m, msg.Width, msg.Height)
+		return sizeLogView(m), nil
+	}
+	return m, nil
+}
+
+// renderLogLines formats entries like 'journalctl -o short', coloured by priority.
+func renderLogLines(entries []system.JournalEntry) string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		prefix := e.Time.Format("Jan 02 15:04:05") + " " + e.Identifier
+		if e.PID != 0 {
+			prefix += fmt.Sprintf("[%d]", e.PID)
+		}
+		style := styles.PriorityStyle(e.Priority)
+		for _, line := range strings.Split(e.Message, "\n") {
+			lines = append(lines, style.Render(prefix+": "+line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// logsCommandLine is the journalctl command the Logs screen shows, the
+// equivalent 'journalctl -f' one while following. While the filter bar is
+// open it previews the filters being edited.
+func logsCommandLine(m model) string {
+	q := m.logs.query
+	if m.logs.filter.editing {
+		q = m.logs.filter.query(q)
+	}
+	q.Follow = m.logs.following
+	args := q.Args(hostOptions(m, m.logs.unit.Host))
+	return system.ShellQuote(append([]string{"journalctl"}, args...))
+}
+
+// renderLogsView renders the Logs screen: the journalctl command, the
+// entries and a status footer, in the same box as command output.
+func renderLogsView(m model) string {
+	outputStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#5A56E0")).
+		Padding(1, 2)
+
+	commandLine := styles.TabActiveStyle.MaxWidth(m.width - pagerChromeWidth).Render("> " + logsCommandLine(m))
+
+	body := m.logs.view.View()
+	switch {
+	case m.logs.err != nil && len(m.logs.entries) == 0:
+		body = lipgloss.NewStyle().Height(m.logs.view.Height).Render("Failed to read the journal:\n" + m.logs.err.Error())
+	case m.logs.loading && len(m.logs.entries) == 0:
+		body = lipgloss.NewStyle().Height(m.logs.view.Height).Render("Loading...")
+	case len(m.logs.entries) == 0 && logsFiltered(m.logs.query):
+		body = lipgloss.NewStyle().Height(m.logs.view.Height).Render("No journal entries match the filters.")
+	case len(m.logs.entries) == 0:
+		body = lipgloss.NewStyle().Height(m.logs.view.Height).Render("No journal entries.")
+	}
+
+	content := []string{commandLine}
+	if m.logs.filter.editing {
+		content = append(content, renderLogFilter(m))
+	}
+	content = append(content, body, renderLogsFooter(m))
+	return outputStyle.Render(lipgloss.JoinVertical(lipgloss.Left, content...))
+}
+
+// renderLogsFooter shows where the view is and the keys of the Logs screen.
+func renderLogsFooter(m model) string {
+	v := m.logs.view
+	status := fmt.Sprintf("%d entries | lines %d-%d/%d", len(m.logs.entries), v.YOffset+1, v.YOffset+v.VisibleLineCount(), v.TotalLineCount())
+	switch {
+	case m.logs.paused:
+		status += fmt.Sprintf(" | paused, %d new", len(m.logs.pending))
+	case m.logs.following:
+		status += " | following"
+	}
+	switch {
+	case m.logs.loading:
+		status += " | loading..."
+	case m.logs.err != nil:
+		status += " | " + m.logs.err.Error()
+	case !m.logs.older:
+		status += " | start of journal"
+	}
+	keys := "↑/↓ pgup/pgdn: scroll | ←/→: pan | o: older | r: reload | /: filter | F: follow | Esc/q: close"
+	if logsFiltered(m.logs.query) {
+		keys = "c: clear filters | " + keys
+	}
+	if m.logs.following {
+		keys = "p: pause/resume | " + keys
+	}
+	return styles.FooterStyle.MaxWidth(m.width - pagerChromeWidth).Render(status + " | " + keys)
+}
@@ -0,0 +1,97 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
+)
+
+// switchBackend reopens the backend with opts and shows its units. Units
+// seen earlier for the same scope and target are shown straight from the
+// cache while a fresh copy is fetched; otherwise they are loaded from scratch.
+// On failure the current backend is kept and the error is shown.
+func switchBackend(m model, opts system.Options) (model, tea.Cmd) {
+	backend, err := system.Open(opts, m.executor)
+	if err != nil {
+		m.commandOutput = fmt.Sprintf("Cannot switch to %s: %v", managerLabel(opts), err)
+		m.state = StateOutput
+		return m, nil
+	}
+
+	// Remember what we had so switching back is instant
+	// (the fleet view's merged list is never cached)
+	if m.FullUnitList != nil && !m.fleetMode {
+		m.unitCache[cacheKey(m.options)] = m.FullUnitList
+	}
+	m.fleetMode = false
+	m.fleetErrors = nil
+
+	m = stopLiveUpdates(m)
+	_ = m.backend.Close()
+	m.backend = backend
+	m.options = opts
+	m.backendGen++ // orphan the old backend's ticks and events
+	m.refreshErr = nil
+	m = resetPane(m)
+	m.marked = make(map[string]bool) // marks are per unit list
+	m.systemStates = nil
+
+	// Unit names are per manager, so the old selection no longer applies
+	m.selectedUnit, m.selectedHost = "", ""
+	m.loadErr = nil
+
+	if cached, ok := m.unitCache[cacheKey(opts)]; ok {
+		var cmd, liveCmd tea.Cmd
+		m.state = StateBrowse
+		m, cmd = setUnits(m, cached)
+		m, liveCmd = startLiveUpdates(m)
+		// The refresh result also restarts the polling loop
+		return m, tea.Batch(cmd, liveCmd, fetchUnits(m))
+	}
+
+	m.state = StateLoading
+	return m, tea.Batch(m.spinner.Tick, loadUnits(m.backend, m.loadTimeout))
+}
+
+// cacheKey identifies the unit list of one manager on one target.
+func cacheKey(opts system.Options) string {
+	return opts.Scope.String() + "@" + opts.Target.String()
+}
+
+// managerLabel describes the manager selected by opts, e.g. "user manager on web1".
+func managerLabel(opts system.Options) string {
+	return fmt.Sprintf("%s manager on %s", opts.Scope, opts.Target)
+}
+
+// toggleScope switches between the system and the user manager.
+func toggleScope(m model) (model, tea.Cmd) {
+	opts := m.options
+	if opts.Scope == system.ScopeUser {
+		opts.Scope = system.ScopeSystem
+	} else {
+		opts.Scope = system.ScopeUser
+	}
+	return switchBackend(m, opts)
+}
+
+// actionSpec describes 'systemctl verb' against the manager the selected
+// unit lives on, with the selected unit if the verb takes one.
+func actionSpec(m model, verb string) system.CommandSpec {
+	spec := system.NewSystemctlSpec(actionOptions(m), verb)
+	if m.selectedUnit != "" && spec.UnitRule != system.UnitNone {
+		spec.Units = []string{m.selectedUnit}
+	}
+	return spec
+}
+
+// submitSpec runs spec as a new job through the backend of the selected
+// unit's host, titled with its shell-quoted command line.
+func submitSpec(m model, spec system.CommandSpec) (model, tea.Cmd) {
+	backend, ex := actionBackend(m), m.executor
+	return submitJob(m, spec.String(), func(ctx context.Context, id int) tea.Cmd {
+		return system.StreamSpecAsync(ctx, id, backend, ex, spec)
+	})
+}
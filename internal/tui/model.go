@@ -2,8 +2,12 @@
 package tui
 
 import (
-	"sort"
+	"context"
+	"time"
+
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"systemctltui/internal/listui"
 	"systemctltui/internal/constants"
@@ -18,6 +22,9 @@ const (
 	StatePreview                  // Showing command preview
 	StateOutput                   // Showing command output/error
 	StateFiltering                // Showing unit filter dialog
+	StateLoading                  // Waiting for the initial unit list
+	StateTargetPicker             // Choosing the host or container to manage
+	StateLogs                     // Reading the journal of a unit
 )
 
 // model represents the main state of the TUI application.
@@ -32,55 +39,135 @@ type model struct {
 	state           AppState
 	selectedCommand string
 	selectedUnit    string
+	selectedHost    string // host of selectedUnit in the fleet view; empty otherwise
 	previewCommand  string
+	previewSpec     system.CommandSpec // what previewCommand shows; run on confirmation
+	optionBuilder   optionBuilder      // the previewed verb's options
 	commandOutput   string
+	detailsKey      string // Unit.Key of the unit whose details the output view is waiting for
+	triageKey       string // likewise for a failure report, see triage.go
+	pager           pagerState // scrolling and search on the output screen
+
+	// Job queue: every command run from the UI is a job. The output screen
+	// shows viewedJob, or plain text (e.g. unit details) when it is zero.
+	jobs           []job
+	nextJobID      int
+	viewedJob      int
+	maxJobs        int           // jobs running at once; the rest wait
+	jobsTicking    bool          // a jobsTickMsg is pending
+	commandTimeout time.Duration // kill jobs after this long; zero means never
+
+	// systemd's own job queue, shown in the Jobs tab
+	managerJobs    []system.ManagerJob
+	managerJobsErr error
+	managerJobsSeq int // bumped to restart polling
+
+	// executor runs every external command (systemctl and friends).
+	executor system.Executor
+	// backend talks to the service manager (systemctl or D-Bus),
+	// opened with options.
+	backend system.Backend
+	options system.Options
+	// backendGen is bumped whenever backend is replaced; live and refresh
+	// messages started under an older generation are ignored.
+	backendGen int
+	// liveUpdates is true while the backend is pushing unit changes.
+	liveUpdates bool
+	stopLive    context.CancelFunc // cancels the current subscription
+
+	// Polling refresh, used whenever live updates are unavailable
+	refreshInterval time.Duration
+	refreshErr      error // last refresh failure, shown in the footer
+	highlightGen    int   // bumped on every refresh that highlights units
+
+	// Health header above the tabs (see health.go): the manager state from
+	// 'systemctl is-system-running' per Unit.Host, "" outside the fleet view
+	systemStates map[string]string
+
+	// Initial unit loading (StateLoading)
+	spinner     spinner.Model
+	loadTimeout time.Duration
+	loadErr     error // set when the initial load failed; offers a retry
+
+	// Target selection (StateTargetPicker). unitCache holds the last unit
+	// list seen per scope and target, keyed by cacheKey.
+	knownTargets []system.Target
+	targetList   list.Model
+	targetInput  textinput.Model
+	addingTarget bool
+	unitCache    map[string][]system.Unit
+
+	// Fleet view: units from every known target in one list
+	fleetMode       bool
+	fleetFailedOnly bool
+	fleetErrors     []system.HostResult // targets that failed on the last fetch
+	fleetWorkers    int
+
+	// Detail pane next to the Units list, following the cursor
+	pane detailPane
+
+	// Logs screen (StateLogs)
+	logs logView
+
+	// Multi-select: units marked with space, keyed by Unit.Key. Batch
+	// actions run the chosen command on every marked unit.
+	marked      map[string]bool
+	batchUnits  []system.Unit // the marked units the previewed batch runs on
+	batches     []batch
+	nextBatchID int
+	viewedBatch int // the batch whose summary the output screen shows, if any
 
 	// State for unit filtering
 	FullUnitList      []system.Unit
-	filterList        list.Model
-	currentUnitFilter string
+	filterList        list.Model // facet values in the filter dialog (StateFiltering)
+	unitFilter        unitFilter // the facets picked there
+}
+
+// Config holds the startup settings of the TUI.
+type Config struct {
+	// Options are the ones backend was opened with; they are reused to
+	// reopen it when switching scope or target.
+	Options system.Options
+	// Targets are offered in the target picker besides the local machine,
+	// and make up the fleet view.
+	Targets []system.Target
+	// FleetWorkers bounds concurrent fetches in the fleet view.
+	// Zero means defaultFleetWorkers.
+	FleetWorkers int
+	// RefreshInterval is how often the unit list is re-fetched when the
+	// backend cannot push live updates. Zero disables polling.
+	RefreshInterval time.Duration
+	// MaxJobs bounds how many commands run at once. Zero means defaultMaxJobs.
+	MaxJobs int
+	// CommandTimeout kills commands run from the output screen after this
+	// long. Zero means they may run forever.
+	CommandTimeout time.Duration
+	// LoadTimeout bounds the initial unit load. Zero means defaultLoadTimeout.
+	LoadTimeout time.Duration
 }
 
 // NewModel initializes the main application model.
-func NewModel() model {
-	tabs := []string{"Global Options", "Commands", "Units"}
+// Units are listed and actions run through backend; other commands are run
+// through ex. Pass a system.FakeExecutor (and a SystemctlBackend wrapping it)
+// to drive the model from recorded fixtures instead of a real systemd.
+// No units are fetched here: Init starts loading them in the background.
+func NewModel(ex system.Executor, backend system.Backend, cfg Config) model {
+	tabs := []string{"Global Options", "Commands", "Units", "Jobs", "Failed"}
 	lists := listui.NewLists()
 
-	// Extract the full list of units
-	var fullUnitList []system.Unit
-	unitsListModel := lists[constants.TabUnits]
-	for _, item := range unitsListModel.Items() {
-		if listItem, ok := item.(listui.ListItem); ok {
-			fullUnitList = append(fullUnitList, listItem.Unit)
-		}
+	loadTimeout := cfg.LoadTimeout
+	if loadTimeout <= 0 {
+		loadTimeout = defaultLoadTimeout
 	}
-
-	// Determine unique unit types
-	uniqueTypes := make(map[string]bool)
-	uniqueTypes["All"] = true
-	for _, unit := range fullUnitList {
-		if unit.Type != "" {
-			uniqueTypes[unit.Type] = true
-		}
+	fleetWorkers := cfg.FleetWorkers
+	if fleetWorkers <= 0 {
+		fleetWorkers = defaultFleetWorkers
 	}
-
-	// Build filter items
-	var filterItems []listui.SimpleListItem
-	for unitType := range uniqueTypes {
-		filterItems = append(filterItems, listui.SimpleListItem{
-			TitleValue: unitType,
-			DescValue:  "",
-		})
+	maxJobs := cfg.MaxJobs
+	if maxJobs <= 0 {
+		maxJobs = defaultMaxJobs
 	}
 
-	// Sort filter items alphabetically
-	sort.Slice(filterItems, func(i, j int) bool {
-		return filterItems[i].TitleValue < filterItems[j].TitleValue
-	})
-
-	// Create the filter list model
-	filterList := listui.CreateSimpleList(filterItems)
-
 	return model{
 		activeTab:        constants.TabOptions,
 		tabs:             tabs,
@@ -88,18 +175,40 @@ func NewModel() model {
 		showHelp:         false,
 		width:            0,
 		height:           0,
-		state:            StateBrowse,
-
-		FullUnitList:      fullUnitList,
-		filterList:        filterList,
-		currentUnitFilter: "All",
+		state:            StateLoading,
+		executor:         ex,
+		backend:          backend,
+		options:          cfg.Options,
+		refreshInterval:  cfg.RefreshInterval,
+		loadTimeout:      loadTimeout,
+		commandTimeout:   cfg.CommandTimeout,
+		maxJobs:          maxJobs,
+		spinner:          spinner.New(spinner.WithSpinner(spinner.Dot)),
+		knownTargets:     knownTargets(cfg),
+		targetInput:      newTargetInput(),
+		pager:            newPager(),
+		unitCache:        make(map[string][]system.Unit),
+		marked:           make(map[string]bool),
+		fleetWorkers:     fleetWorkers,
+
+		filterList:        listui.CreateFacetList(nil),
+		unitFilter:        make(unitFilter),
 	}
 }
 
 // Init performs initial setup for the bubbletea model.
 func (m model) Init() tea.Cmd {
-	return nil
+	// Load units in the background; live updates and polling start once
+	// the first load has succeeded (see updateLoading)
+	return tea.Batch(m.spinner.Tick, loadUnits(m.backend, m.loadTimeout))
 }
 
-// Update and View methods are defined in update.go and view.go
+// Close stops live updates and closes the backend the model ended up
+// with, which need not be the one it was created with (see switchBackend).
+// Call it on the final model returned by tea.Program.Run.
+func (m model) Close() error {
+	m = stopLiveUpdates(m)
+	return m.backend.Close()
+}
 
+// Update and View methods are defined in update.go and view.go
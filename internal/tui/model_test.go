@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/system"
+)
+
+// fixtureExecutor replays the recorded systemd in testdata.
+func fixtureExecutor(t *testing.T) *system.FakeExecutor {
+	t.Helper()
+	ex := system.NewFakeExecutor()
+	fixtures := []struct {
+		file string
+		args []string
+	}{
+		{"list-units.txt", []string{"list-units", "--all", "--no-legend", "--plain", "--output=json"}},
+		{"show-fwupd-refresh.txt", []string{"show", "--no-pager", "fwupd-refresh.service"}},
+	}
+	for _, f := range fixtures {
+		if err := ex.OnFixture(filepath.Join("testdata", f.file), "systemctl", f.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ex.On(system.FakeResponse{Stdout: "degraded\n", ExitCode: 1}, "systemctl", "is-system-running")
+	return ex
+}
+
+// startModel runs NewModel and Init against ex until the units are listed.
+func startModel(t *testing.T, ex *system.FakeExecutor) model {
+	t.Helper()
+	m := NewModel(ex, system.NewSystemctlBackend(ex, system.Options{}), Config{})
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = send(t, m, runCmd(m.Init())...)
+	if m.state != StateBrowse {
+		t.Fatalf("state %v after loading (error %v), want browsing", m.state, m.loadErr)
+	}
+	return m
+}
+
+// selectTitle moves the cursor of tab's list to the item titled title.
+func selectTitle(t *testing.T, m model, tab int, title string) model {
+	t.Helper()
+	for i, item := range m.lists[tab].Items() {
+		if item.(list.DefaultItem).Title() == title {
+			m.lists[tab].Select(i)
+			return m
+		}
+	}
+	t.Fatalf("no %q in tab %d", title, tab)
+	return m
+}
+
+func TestModelFromFixtures(t *testing.T) {
+	ex := fixtureExecutor(t)
+	m := startModel(t, ex)
+
+	if got := len(m.FullUnitList); got != 7 {
+		t.Errorf("%d units listed, want 7", got)
+	}
+	if got := m.systemStates[""]; got != "degraded" {
+		t.Errorf("system state %q, want degraded", got)
+	}
+	if got := listedNames(m); !slices.Contains(got, "fwupd-refresh.service") {
+		t.Errorf("Units tab lacks the failed unit: %q", got)
+	}
+	if got := len(m.lists[constants.TabFailed].Items()); got != 1 {
+		t.Errorf("Failed tab has %d units, want 1", got)
+	}
+
+	// Pick the failed unit on the Units tab: its details come from 'systemctl show'
+	m, _ = send(t, m, key("tab"), key("tab"))
+	if m.activeTab != constants.TabUnits {
+		t.Fatalf("on tab %d, want Units", m.activeTab)
+	}
+	m = selectTitle(t, m, constants.TabUnits, "fwupd-refresh.service")
+	m, _ = send(t, m, key("enter"))
+	if m.state != StateOutput || m.selectedUnit != "fwupd-refresh.service" {
+		t.Fatalf("state %v, unit %q after enter", m.state, m.selectedUnit)
+	}
+	for _, want := range []string{"fwupd-refresh.service - Refresh fwupd metadata", "failed (failed)", "exit-code"} {
+		if !strings.Contains(m.commandOutput, want) {
+			t.Errorf("details lack %q:\n%s", want, m.commandOutput)
+		}
+	}
+	m, _ = send(t, m, key("esc"))
+
+	// Preview a restart of it from the Commands tab, back out, then run it
+	m, _ = send(t, m, key("shift+tab"))
+	m = selectTitle(t, m, constants.TabCommands, "restart")
+	m, _ = send(t, m, key("enter"))
+	want := "systemctl restart -- fwupd-refresh.service"
+	if m.state != StatePreview || m.previewCommand != want {
+		t.Fatalf("state %v, preview %q; want %q", m.state, m.previewCommand, want)
+	}
+	m, _ = send(t, m, key("esc"))
+	if m.state != StateBrowse {
+		t.Fatalf("state %v after esc, want browsing", m.state)
+	}
+	restart := []string{"systemctl", "restart", "--", "fwupd-refresh.service"}
+	for _, call := range ex.Calls() {
+		if slices.Equal(call, restart) {
+			t.Fatal("the restart ran although the preview was cancelled")
+		}
+	}
+
+	ex.On(system.FakeResponse{Stdout: "restarted\n"}, restart[0], restart[1:]...)
+	m, _ = send(t, m, key("enter"), key("enter"))
+	if m.state != StateOutput {
+		t.Fatalf("state %v after confirming, want output", m.state)
+	}
+	if !slices.ContainsFunc(ex.Calls(), func(call []string) bool { return slices.Equal(call, restart) }) {
+		t.Errorf("restart not run; calls %q", ex.Calls())
+	}
+	if len(m.jobs) != 1 || m.jobs[0].status != jobSucceeded || !strings.Contains(m.commandOutput, "restarted") {
+		t.Errorf("jobs %+v, output %q", m.jobs, m.commandOutput)
+	}
+}
+
+// TestPreviewRequiresUnit checks that a verb needing a unit is refused
+// before anything runs when none has been picked.
+func TestPreviewRequiresUnit(t *testing.T) {
+	ex := fixtureExecutor(t)
+	m := startModel(t, ex)
+	calls := len(ex.Calls())
+
+	m, _ = send(t, m, key("tab"))
+	m = selectTitle(t, m, constants.TabCommands, "stop")
+	m, _ = send(t, m, key("enter"))
+	if m.state != StateOutput || !strings.Contains(m.commandOutput, "requires a unit") {
+		t.Errorf("state %v, output %q", m.state, m.commandOutput)
+	}
+	if got := len(ex.Calls()); got != calls {
+		t.Errorf("%d commands ran", got-calls)
+	}
+}
+
+// closeCounter is a backend that counts how often it is closed.
+type closeCounter struct {
+	system.Backend
+	closed int
+}
+
+func (b *closeCounter) Close() error {
+	b.closed++
+	return nil
+}
+
+// TestCloseAfterSwitch checks that the startup backend is closed exactly
+// once when the user switched away from it, and the current one on exit.
+func TestCloseAfterSwitch(t *testing.T) {
+	ex := fixtureExecutor(t)
+	startup := &closeCounter{Backend: system.NewSystemctlBackend(ex, system.Options{})}
+	m := NewModel(ex, startup, Config{})
+
+	m, _ = switchBackend(m, system.Options{Scope: system.ScopeUser})
+	current := &closeCounter{Backend: m.backend}
+	m.backend = current
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if startup.closed != 1 || current.closed != 1 {
+		t.Errorf("startup closed %d times, current %d; want once each", startup.closed, current.closed)
+	}
+}
+
+// TestScopeKey checks that "u" is left to the lists as page-up and "U"
+// switches to the user manager.
+func TestScopeKey(t *testing.T) {
+	ex := fixtureExecutor(t)
+	ex.On(system.FakeResponse{Stdout: "a.service loaded active running A\n"}, "systemctl", "--user", "list-units", "--all", "--no-legend", "--plain", "--output=json")
+	ex.On(system.FakeResponse{Stdout: "running\n"}, "systemctl", "--user", "is-system-running")
+	m := startModel(t, ex)
+	m, _ = send(t, m, key("tab"), key("tab"))
+
+	// A short terminal, so the units span several pages
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 12})
+	m, _ = setUnits(m, m.FullUnitList)
+	m.lists[constants.TabUnits].Select(len(m.lists[constants.TabUnits].Items()) - 1)
+	page := m.lists[constants.TabUnits].Paginator.Page
+	m, _ = send(t, m, key("u"))
+	if m.options.Scope != system.ScopeSystem {
+		t.Fatal("u switched the manager")
+	}
+	if got := m.lists[constants.TabUnits].Paginator.Page; page == 0 || got != page-1 {
+		t.Errorf("page %d after u, want %d", got, page-1)
+	}
+
+	m, _ = send(t, m, key("U"))
+	if m.options.Scope != system.ScopeUser || len(m.FullUnitList) != 1 {
+		t.Errorf("scope %v with %d units after U, want the user manager's", m.options.Scope, len(m.FullUnitList))
+	}
+}
+
+// TestResizeOffBrowse checks that the lists follow a resize made on the
+// preview and output screens, so they fit on going back to browsing.
+func TestResizeOffBrowse(t *testing.T) {
+	ex := fixtureExecutor(t)
+	m := startModel(t, ex)
+	m, _ = send(t, m, key("tab"), key("tab"))
+	m = selectTitle(t, m, constants.TabUnits, "fwupd-refresh.service")
+	m, _ = send(t, m, key("enter"))
+	if m.state != StateOutput {
+		t.Fatalf("state %v, want the unit's details", m.state)
+	}
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 80, Height: 20})
+	if w, h := m.lists[constants.TabUnits].Width(), m.lists[constants.TabUnits].Height(); w != unitsListWidth(m) || h != 15 {
+		t.Errorf("Units list %dx%d after resizing the output, want %dx15", w, h, unitsListWidth(m))
+	}
+
+	m, _ = send(t, m, key("esc"), key("shift+tab"))
+	m = selectTitle(t, m, constants.TabCommands, "restart")
+	m, _ = send(t, m, key("enter"))
+	if m.state != StatePreview {
+		t.Fatalf("state %v, want the preview", m.state)
+	}
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 90, Height: 30})
+	if w, h := m.lists[constants.TabCommands].Width(), m.lists[constants.TabCommands].Height(); w != 90 || h != 25 {
+		t.Errorf("Commands list %dx%d after resizing the preview, want 90x25", w, h)
+	}
+}
@@ -0,0 +1,208 @@
+// package tui
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system"
+)
+
+// optionBuilder is the form in the preview screen for the options of the
+// previewed verb. Plain flags are toggled; valued options cycle through
+// their choices, starting unset, or take a value typed in (e.g. a signal
+// like SIGRTMIN+3 that is not among the choices).
+type optionBuilder struct {
+	verb    system.Verb
+	options []system.Option
+	values  map[string]string // set options; "" for a plain flag
+	cursor  int
+
+	editing bool            // typing the highlighted option's value
+	input   textinput.Model // the value being typed
+	err     string          // why the typed value was refused
+}
+
+func newOptionBuilder(verb string) optionBuilder {
+	info, _ := system.LookupVerb(verb)
+	b := optionBuilder{verb: info, values: make(map[string]string)}
+	for _, name := range info.Options {
+		b.options = append(b.options, system.OptionInfo(name))
+	}
+	b.input = textinput.New()
+	b.input.Prompt = ""
+	b.input.CharLimit = 64
+	return b
+}
+
+// args are the set options in catalogue order, e.g. [--now --signal=SIGHUP].
+func (b optionBuilder) args() []string {
+	var args []string
+	for _, o := range b.options {
+		value, ok := b.values[o.Name]
+		switch {
+		case !ok:
+		case len(o.Values) == 0:
+			args = append(args, o.Name)
+		default:
+			args = append(args, o.Name+"="+value)
+		}
+	}
+	return args
+}
+
+// step toggles the highlighted flag, or moves its value by delta through
+// unset and its choices.
+func (b optionBuilder) step(delta int) optionBuilder {
+	if len(b.options) == 0 {
+		return b
+	}
+	o := b.options[b.cursor]
+	if len(o.Values) == 0 {
+		if _, ok := b.values[o.Name]; ok {
+			delete(b.values, o.Name)
+		} else {
+			b.values[o.Name] = ""
+		}
+		return b
+	}
+
+	// Position 0 is unset, 1..n the choices
+	pos := 0
+	if v, ok := b.values[o.Name]; ok {
+		for i, choice := range o.Values {
+			if choice == v {
+				pos = i + 1
+			}
+		}
+	}
+	n := len(o.Values) + 1
+	pos = ((pos+delta)%n + n) % n
+	if pos == 0 {
+		delete(b.values, o.Name)
+	} else {
+		b.values[o.Name] = o.Values[pos-1]
+	}
+	return b
+}
+
+// edit starts typing a value for the highlighted option, if it takes one.
+func (b optionBuilder) edit() (optionBuilder, tea.Cmd) {
+	if len(b.options[b.cursor].Values) == 0 {
+		return b, nil
+	}
+	b.editing, b.err = true, ""
+	b.input.SetValue(b.values[b.options[b.cursor].Name])
+	b.input.CursorEnd()
+	return b, b.input.Focus()
+}
+
+// commit sets the highlighted option to the typed value, unless the verb
+// would refuse it. An empty value unsets the option.
+func (b optionBuilder) commit() optionBuilder {
+	name, value := b.options[b.cursor].Name, b.input.Value()
+	switch {
+	case value == "":
+		delete(b.values, name)
+	case !b.verb.AcceptsOption(name + "=" + value):
+		b.err = fmt.Sprintf("'%s' does not accept %s=%s", b.verb.Name, name, value)
+		return b
+	default:
+		b.values[name] = value
+	}
+	b.editing, b.err = false, ""
+	b.input.Blur()
+	return b
+}
+
+// updateOptionKeys handles the option builder keys of the preview screen
+// and re-renders the previewed command. It reports whether it used the key;
+// while a value is typed in, it uses them all.
+func updateOptionKeys(m model, msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	b := m.optionBuilder
+	if len(b.options) == 0 {
+		return m, nil, false
+	}
+	var cmd tea.Cmd
+	if b.editing {
+		switch msg.String() {
+		case "enter":
+			b = b.commit()
+		case "esc":
+			b.editing, b.err = false, ""
+			b.input.Blur()
+		default:
+			b.input, cmd = b.input.Update(msg)
+		}
+		m.optionBuilder = b
+		return rebuildPreview(m), cmd, true
+	}
+	switch msg.String() {
+	case "up", "k":
+		b.cursor = (b.cursor - 1 + len(b.options)) % len(b.options)
+	case "down", "j":
+		b.cursor = (b.cursor + 1) % len(b.options)
+	case " ", "right", "l":
+		b = b.step(1)
+	case "left", "h":
+		b = b.step(-1)
+	case "e":
+		b, cmd = b.edit()
+	default:
+		return m, nil, false
+	}
+	m.optionBuilder = b
+	return rebuildPreview(m), cmd, true
+}
+
+// rebuildPreview applies the option builder to the previewed command.
+func rebuildPreview(m model) model {
+	args := m.optionBuilder.args()
+	if len(m.batchUnits) > 0 {
+		m.previewCommand = batchPreview(m, m.selectedCommand, args, m.batchUnits)
+		return m
+	}
+	m.previewSpec.Args = args
+	m.previewCommand = m.previewSpec.String()
+	return m
+}
+
+// renderOptionBuilder lists the verb's options with their current setting.
+func renderOptionBuilder(b optionBuilder) []string {
+	if len(b.options) == 0 {
+		return nil
+	}
+	lines := []string{"Options:"}
+	for i, o := range b.options {
+		value, set := b.values[o.Name]
+		var setting string
+		switch {
+		case len(o.Values) == 0 && set:
+			setting = "[x] " + o.Name
+		case len(o.Values) == 0:
+			setting = "[ ] " + o.Name
+		case i == b.cursor && b.editing:
+			setting = fmt.Sprintf("    %s=%s", o.Name, b.input.View())
+		case set:
+			setting = fmt.Sprintf("    %s=%s", o.Name, value)
+		default:
+			setting = fmt.Sprintf("    %s=(default)", o.Name)
+		}
+		line := fmt.Sprintf("%-28s %s", setting, o.Description)
+		if i == b.cursor {
+			line = styles.SearchCurrentStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	if b.editing {
+		if b.err != "" {
+			lines = append(lines, "", styles.WarningStyle.Render(b.err))
+		}
+		return append(lines, "", "Enter: set value (empty to unset) | Esc: keep the old one")
+	}
+	return append(lines, "", "↑/↓: choose option | Space/←/→: toggle or change value | e: type a value")
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+)
+
+// previewKill previews 'systemctl kill' on the failed unit of the fixtures.
+func previewKill(t *testing.T) model {
+	t.Helper()
+	m := startModel(t, fixtureExecutor(t))
+	m, _ = send(t, m, key("tab"), key("tab"))
+	m = selectTitle(t, m, constants.TabUnits, "fwupd-refresh.service")
+	m, _ = send(t, m, key("enter"), key("esc"), key("shift+tab"))
+	m = selectTitle(t, m, constants.TabCommands, "kill")
+	m, _ = send(t, m, key("enter"))
+	if m.state != StatePreview {
+		t.Fatalf("state %v, want the preview", m.state)
+	}
+	return m
+}
+
+// typeText sends s as typed runes.
+func typeText(t *testing.T, m model, s string) model {
+	t.Helper()
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+	return m
+}
+
+func TestOptionChoices(t *testing.T) {
+	m := previewKill(t)
+	m, _ = send(t, m, key("right"), key("right"), key("down"), key("left"))
+	if got, want := m.optionBuilder.args(), []string{"--signal=SIGHUP", "--kill-whom=control"}; !slices.Equal(got, want) {
+		t.Errorf("args %q, want %q", got, want)
+	}
+	if want := "systemctl kill --signal=SIGHUP --kill-whom=control -- fwupd-refresh.service"; m.previewCommand != want {
+		t.Errorf("preview %q, want %q", m.previewCommand, want)
+	}
+}
+
+func TestOptionTypedValue(t *testing.T) {
+	m := previewKill(t)
+
+	m, _ = send(t, m, key("e"))
+	if !m.optionBuilder.editing {
+		t.Fatal("e did not start typing the --signal value")
+	}
+	m = typeText(t, m, "SIGRTMIN+3")
+	m, _ = send(t, m, key("enter"))
+	if m.state != StatePreview || m.optionBuilder.editing {
+		t.Fatalf("state %v, editing %v: enter should only set the value", m.state, m.optionBuilder.editing)
+	}
+	if !strings.Contains(m.previewCommand, "--signal=SIGRTMIN+3") {
+		t.Errorf("preview %q lacks the typed signal", m.previewCommand)
+	}
+
+	// Esc drops an edit, leaving the value and the preview open
+	m, _ = send(t, m, key("e"))
+	m = typeText(t, m, "x")
+	m, _ = send(t, m, key("esc"))
+	if m.state != StatePreview || m.optionBuilder.values["--signal"] != "SIGRTMIN+3" {
+		t.Errorf("state %v, --signal=%s after esc", m.state, m.optionBuilder.values["--signal"])
+	}
+
+	// Emptying the value unsets the option
+	m, _ = send(t, m, key("e"))
+	for range len("SIGRTMIN+3") {
+		m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m, _ = send(t, m, key("enter"))
+	if strings.Contains(m.previewCommand, "--signal") {
+		t.Errorf("preview %q still sends a signal", m.previewCommand)
+	}
+}
+
+// TestOptionTypedValueRefused checks that the builder only takes what the
+// verb accepts.
+func TestOptionTypedValueRefused(t *testing.T) {
+	m := previewKill(t)
+	b := m.optionBuilder
+	b, _ = b.edit()
+	b.input.SetValue("")
+	b.verb.Options = []string{"--kill-whom"} // --signal no longer accepted
+	b = b.commit()
+	if b.editing || len(b.values) != 0 {
+		t.Errorf("an empty value should unset the option: editing %v, values %v", b.editing, b.values)
+	}
+
+	b, _ = b.edit()
+	b.input.SetValue("SIGUSR1")
+	b = b.commit()
+	if !b.editing || b.err == "" || len(b.values) != 0 {
+		t.Errorf("value taken although refused: editing %v, err %q, values %v", b.editing, b.err, b.values)
+	}
+
+	// Plain flags take no typed value
+	m.optionBuilder = newOptionBuilder("start")
+	if b, _ := m.optionBuilder.edit(); b.editing {
+		t.Error("typing a value for --no-block")
+	}
+}
@@ -0,0 +1,251 @@
+// package tui
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/styles"
+)
+
+// Vertical space the output box takes around the pager: border and padding
+// (4), the command line with its underline (2) and the footer (2).
+const pagerChromeHeight = 8
+
+// Horizontal space the output box takes around the pager: border (2) and padding (4).
+const pagerChromeWidth = 6
+
+// pagerHorizontalStep is how many columns left/right scroll wide lines by.
+const pagerHorizontalStep = 8
+
+// pagerState is the scrolling and search state of the output screen.
+type pagerState struct {
+	view      viewport.Model
+	source    string // the commandOutput the view was last built from
+	search    textinput.Model
+	searching bool   // the search prompt has focus
+	term      string // the last confirmed search term
+	matches   []int  // line numbers containing term
+	current   int    // index into matches of the highlighted match
+}
+
+func newPager() pagerState {
+	view := viewport.New(0, 0)
+	view.SetHorizontalStep(pagerHorizontalStep)
+
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+	search.CharLimit = 256
+	return pagerState{view: view, search: search}
+}
+
+// syncPager keeps the pager in step with m.commandOutput and the terminal
+// size after every update, so the many places that set commandOutput don't
+// have to know about it.
+func syncPager(next tea.Model, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	m, ok := next.(model)
+	if !ok {
+		return next, cmd
+	}
+
+	width, height := m.width-pagerChromeWidth, m.height-pagerChromeHeight
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	resized := m.pager.view.Width != width || m.pager.view.Height != height
+	m.pager.view.Width, m.pager.view.Height = width, height
+
+	if m.commandOutput != m.pager.source {
+		appended := m.pager.source != "" && strings.HasPrefix(m.commandOutput, m.pager.source)
+		follow := appended && m.pager.view.AtBottom()
+		if !appended {
+			// New output; a new command starts at the top without a search
+			m.pager.view.GotoTop()
+			m.pager.view.SetXOffset(0)
+			m.pager.term, m.pager.matches, m.pager.current = "", nil, 0
+		}
+		m.pager.source = m.commandOutput
+		m = renderPagerContent(m)
+		if follow {
+			// Streamed output keeps the newest lines in view, like tail -f
+			m.pager.view.GotoBottom()
+		}
+	} else if resized {
+		m = renderPagerContent(m) // re-clamp the offsets
+	}
+	return m, cmd
+}
+
+// renderPagerContent (re)builds the pager content from commandOutput,
+// highlighting the search matches.
+func renderPagerContent(m model) model {
+	if m.pager.term == "" {
+		m.pager.view.SetContent(m.commandOutput)
+		return m
+	}
+
+	re := searchPattern(m.pager.term)
+	currentLine := -1
+	if len(m.pager.matches) > 0 {
+		currentLine = m.pager.matches[m.pager.current]
+	}
+
+	lines := strings.Split(m.commandOutput, "\n")
+	m.pager.matches = nil
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+		style := styles.SearchMatchStyle
+		if i == currentLine {
+			style = styles.SearchCurrentStyle
+		}
+		lines[i] = re.ReplaceAllStringFunc(line, func(s string) string { return style.Render(s) })
+		m.pager.matches = append(m.pager.matches, i)
+	}
+	if m.pager.current >= len(m.pager.matches) {
+		m.pager.current = 0
+	}
+	m.pager.view.SetContent(strings.Join(lines, "\n"))
+	return m
+}
+
+// searchPattern matches term literally, ignoring case unless term has
+// upper-case letters (like less -i / vim smartcase).
+func searchPattern(term string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(term)
+	if strings.ToLower(term) == term {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// runSearch finds term in the output and jumps to the first match at or
+// below the current scroll position.
+func runSearch(m model, term string) model {
+	m.pager.term = term
+	m.pager.matches, m.pager.current = nil, 0
+	if term == "" {
+		return renderPagerContent(m)
+	}
+
+	re := searchPattern(term)
+	for i, line := range strings.Split(m.commandOutput, "\n") {
+		if re.MatchString(line) {
+			m.pager.matches = append(m.pager.matches, i)
+		}
+	}
+	for i, line := range m.pager.matches {
+		if line >= m.pager.view.YOffset {
+			m.pager.current = i
+			break
+		}
+	}
+	return showCurrentMatch(m)
+}
+
+// stepMatch moves the highlighted match by delta, wrapping around.
+func stepMatch(m model, delta int) model {
+	if len(m.pager.matches) == 0 {
+		return m
+	}
+	n := len(m.pager.matches)
+	m.pager.current = ((m.pager.current+delta)%n + n) % n
+	return showCurrentMatch(m)
+}
+
+// showCurrentMatch re-highlights and scrolls the current match into view.
+func showCurrentMatch(m model) model {
+	m = renderPagerContent(m)
+	if len(m.pager.matches) == 0 {
+		return m
+	}
+	line := m.pager.matches[m.pager.current]
+	if line < m.pager.view.YOffset || line >= m.pager.view.YOffset+m.pager.view.Height {
+		m.pager.view.SetYOffset(line - m.pager.view.Height/3)
+	}
+	return m
+}
+
+// updatePagerKeys handles the scrolling and search keys of the output screen.
+func updatePagerKeys(m model, msg tea.KeyMsg) (model, tea.Cmd) {
+	if m.pager.searching {
+		switch msg.String() {
+		case "esc":
+			m.pager.searching = false
+			m.pager.search.Blur()
+			return m, nil
+		case "enter":
+			m.pager.searching = false
+			m.pager.search.Blur()
+			return runSearch(m, m.pager.search.Value()), nil
+		}
+		var cmd tea.Cmd
+		m.pager.search, cmd = m.pager.search.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "/":
+		m.pager.searching = true
+		m.pager.search.SetValue(m.pager.term)
+		m.pager.search.CursorEnd()
+		return m, m.pager.search.Focus()
+	case "n":
+		return stepMatch(m, 1), nil
+	case "N":
+		return stepMatch(m, -1), nil
+	case "g", "home":
+		m.pager.view.GotoTop()
+		return m, nil
+	case "G", "end":
+		m.pager.view.GotoBottom()
+		return m, nil
+	}
+
+	// Arrows, h/j/k/l, page and half-page keys
+	var cmd tea.Cmd
+	m.pager.view, cmd = m.pager.view.Update(msg)
+	return m, cmd
+}
+
+// pagerStatus is the position and search summary shown in the footer.
+func pagerStatus(m model) string {
+	v := m.pager.view
+	status := fmt.Sprintf("lines %d-%d/%d", v.YOffset+1, v.YOffset+v.VisibleLineCount(), v.TotalLineCount())
+	if v.TotalLineCount() == 0 {
+		status = "empty"
+	}
+	if cmd := commandStatus(m); cmd != "" {
+		status += " | " + cmd
+	}
+	if m.pager.term != "" {
+		if len(m.pager.matches) == 0 {
+			status += fmt.Sprintf(" | /%s: no matches", m.pager.term)
+		} else {
+			status += fmt.Sprintf(" | /%s: %d/%d", m.pager.term, m.pager.current+1, len(m.pager.matches))
+		}
+	}
+	return status
+}
+
+// renderPagerFooter is the last line of the output screen: the search
+// prompt while searching, the scroll status and keys otherwise.
+func renderPagerFooter(m model) string {
+	if m.pager.searching {
+		return styles.FooterStyle.Render(m.pager.search.View())
+	}
+	keys := "↑/↓ pgup/pgdn: scroll | ←/→: pan | /: search | n/N: next/prev | Esc/q: close"
+	if viewedJobRunning(m) {
+		keys = "x/Ctrl+C: cancel | " + keys
+	}
+	return styles.FooterStyle.MaxWidth(m.width - pagerChromeWidth).Render(pagerStatus(m) + " | " + keys)
+}
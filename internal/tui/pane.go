@@ -0,0 +1,202 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system"
+)
+
+const (
+	// paneMinWidth is the narrowest terminal that still gets the detail pane.
+	paneMinWidth = 100
+	// paneDebounce is how long the cursor has to rest on a unit before its
+	// details are fetched.
+	paneDebounce = 200 * time.Millisecond
+	// paneLogLines is how many recent journal lines the pane shows.
+	paneLogLines = 5
+)
+
+// detailPane is the state of the unit detail pane next to the Units list.
+type detailPane struct {
+	unit    system.Unit // the highlighted unit, as listed when it was last fetched
+	seq     int         // bumped for every new fetch; older ticks and results are dropped
+	loading bool
+	details *system.UnitDetails
+	logs    []string
+	err     error
+}
+
+// paneTickMsg fires when the cursor has rested long enough to fetch.
+type paneTickMsg struct{ seq int }
+
+// paneLoadedMsg carries the details and recent logs of the pane's unit.
+type paneLoadedMsg struct {
+	seq     int
+	details system.UnitDetails
+	logs    []string
+	err     error
+}
+
+// paneVisible reports whether the terminal is wide enough for the pane.
+func paneVisible(m model) bool {
+	return m.width >= paneMinWidth
+}
+
+// unitsListWidth is the width left for the Units list beside the pane.
+func unitsListWidth(m model) int {
+	if !paneVisible(m) {
+		return m.width
+	}
+	return m.width * 3 / 5
+}
+
+// followCursor schedules a pane update when the highlighted unit is not the
+// one shown, or its state changed since it was fetched. Nothing is fetched
+// while the pane is hidden.
+func followCursor(m model) (model, tea.Cmd) {
+	if !paneVisible(m) || m.activeTab != constants.TabUnits {
+		return m, nil
+	}
+	item, ok := m.lists[constants.TabUnits].SelectedItem().(listui.ListItem)
+	if !ok {
+		m.pane = detailPane{seq: m.pane.seq}
+		return m, nil
+	}
+	cur := item.Unit
+	if cur == m.pane.unit {
+		return m, nil
+	}
+
+	if cur.Key() != m.pane.unit.Key() {
+		// Don't show the previous unit's details under the new name
+		m.pane = detailPane{seq: m.pane.seq}
+	}
+	m.pane.unit = cur
+	m.pane.seq++
+	m.pane.loading = true
+	seq := m.pane.seq
+	return m, tea.Tick(paneDebounce, func(time.Time) tea.Msg { return paneTickMsg{seq: seq} })
+}
+
+// withPane runs followCursor on the result of an update while browsing.
+func withPane(next tea.Model, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	m, ok := next.(model)
+	if !ok || m.state != StateBrowse {
+		return next, cmd
+	}
+	m, paneCmd := followCursor(m)
+	return m, tea.Batch(cmd, paneCmd)
+}
+
+// resetPane clears the pane and drops any fetch still in flight, e.g. when
+// the backend is switched.
+func resetPane(m model) model {
+	m.pane = detailPane{seq: m.pane.seq + 1}
+	return m
+}
+
+// handlePaneTick fetches the pane's unit once the cursor has settled.
+func handlePaneTick(m model, msg paneTickMsg) tea.Cmd {
+	if msg.seq != m.pane.seq {
+		return nil // the cursor moved on
+	}
+	unit, seq := m.pane.unit, m.pane.seq
+	backend, ex, opts := hostBackend(m, unit.Host), m.executor, hostOptions(m, unit.Host)
+	timeout := m.loadTimeout
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		details, err := backend.UnitDetails(ctx, unit.Name)
+		if err != nil {
+			return paneLoadedMsg{seq: seq, err: err}
+		}
+		// Logs are best effort; the journal may not be readable
+		logs, _ := system.RecentLogs(ctx, ex, opts, unit.Name, paneLogLines)
+		return paneLoadedMsg{seq: seq, details: details, logs: logs}
+	}
+}
+
+// handlePaneLoaded stores a fetch result if it is still the current one.
+func handlePaneLoaded(m model, msg paneLoadedMsg) model {
+	if msg.seq != m.pane.seq {
+		return m
+	}
+	m.pane.loading = false
+	m.pane.err = msg.err
+	if msg.err == nil {
+		details := msg.details
+		m.pane.details = &details
+		m.pane.logs = msg.logs
+	}
+	return m
+}
+
+// renderDetailPane renders the pane at the given outer size.
+func renderDetailPane(m model, width, height int) string {
+	style := styles.DetailPaneStyle
+	innerWidth := width - style.GetHorizontalFrameSize()
+	innerHeight := height - style.GetVerticalFrameSize()
+	if innerWidth < 1 || innerHeight < 1 {
+		return ""
+	}
+
+	var lines []string
+	switch p := m.pane; {
+	case p.unit.Name == "":
+		lines = []string{"No unit highlighted."}
+	case p.details == nil && p.err != nil:
+		lines = []string{p.unit.Name, "", "Failed to load details:", p.err.Error()}
+	case p.details == nil:
+		lines = []string{p.unit.Name, "", "Loading..."}
+	default:
+		lines = paneLines(*p.details, p.logs, time.Now())
+		if p.loading {
+			lines[0] += " (updating)"
+		}
+	}
+
+	content := lipgloss.NewStyle().
+		MaxWidth(innerWidth).
+		MaxHeight(innerHeight).
+		Render(strings.Join(lines, "\n"))
+	return style.Width(width - style.GetHorizontalBorderSize()).
+		Height(height - style.GetVerticalBorderSize()).
+		Render(content)
+}
+
+// paneLines is the pane content for one unit.
+func paneLines(d system.UnitDetails, logs []string, now time.Time) []string {
+	lines := []string{d.Name, d.Description, ""}
+	row := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%-8s %s", label+":", value))
+		}
+	}
+
+	row("State", fmt.Sprintf("%s (%s)", d.ActiveState, d.SubState))
+	if up := d.Uptime(now); up > 0 {
+		row("Uptime", formatDuration(up))
+	}
+	if d.MainPID != 0 {
+		row("PID", fmt.Sprint(d.MainPID))
+	}
+	if d.MemoryCurrent > 0 {
+		row("Memory", formatBytes(d.MemoryCurrent))
+	}
+	row("File", d.FragmentPath)
+
+	if len(logs) > 0 {
+		lines = append(lines, "", "Recent logs:")
+		lines = append(lines, logs...)
+	}
+	return lines
+}
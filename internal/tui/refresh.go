@@ -0,0 +1,194 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/constants"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/system"
+)
+
+const (
+	// highlightDuration is how long changed units stay highlighted after a refresh.
+	highlightDuration = 2 * time.Second
+	// defaultLoadTimeout bounds the initial unit load when Config leaves it unset.
+	defaultLoadTimeout = 15 * time.Second
+)
+
+// unitsLoadedMsg carries the result of the initial (or retried) unit load.
+type unitsLoadedMsg struct {
+	units []system.Unit
+	err   error
+}
+
+// refreshTickMsg fires every refresh interval.
+type refreshTickMsg struct {
+	gen int // backendGen the polling loop was started under
+}
+
+// unitsRefreshedMsg carries the result of re-fetching the unit list.
+type unitsRefreshedMsg struct {
+	gen   int
+	units []system.Unit
+	err   error
+}
+
+// clearHighlightMsg ends the highlight started by refresh number gen.
+type clearHighlightMsg struct {
+	gen int
+}
+
+// loadUnits fetches the unit list for startup, giving up after timeout even
+// if the backend does not honour its context.
+func loadUnits(b system.Backend, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		done := make(chan unitsLoadedMsg, 1)
+		go func() {
+			units, err := b.ListUnits(ctx)
+			done <- unitsLoadedMsg{units: units, err: err}
+		}()
+
+		select {
+		case msg := <-done:
+			return msg
+		case <-ctx.Done():
+			return unitsLoadedMsg{err: fmt.Errorf("timed out after %s waiting for the unit list", timeout)}
+		}
+	}
+}
+
+// setUnits replaces the unit list wholesale after a (re)load.
+func setUnits(m model, units []system.Unit) (model, tea.Cmd) {
+	m.FullUnitList = units
+	cmd := m.lists[constants.TabUnits].SetItems(listui.UnitItems(visibleUnits(m, units), m.marked))
+	m, facetCmd := refreshFacets(m)
+	return m, tea.Batch(cmd, facetCmd)
+}
+
+// visibleUnits narrows units down to the ones the Units list should show
+// under the current view settings (failed-only in the fleet view, and the
+// facets picked in the filter dialog).
+func visibleUnits(m model, units []system.Unit) []system.Unit {
+	if !(m.fleetMode && m.fleetFailedOnly) && len(m.unitFilter.chips()) == 0 {
+		return units
+	}
+	var out []system.Unit
+	for _, u := range units {
+		if unitVisible(m, u) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// unitVisible reports whether u belongs in the Units list.
+func unitVisible(m model, u system.Unit) bool {
+	return fleetVisible(m, u) && m.unitFilter.matches(u, "")
+}
+
+// fleetVisible applies the fleet view's failed-only switch.
+func fleetVisible(m model, u system.Unit) bool {
+	return !(m.fleetMode && m.fleetFailedOnly) || u.Active == "failed"
+}
+
+// scheduleRefresh arms the next polling tick, or does nothing if polling is off.
+func scheduleRefresh(m model) tea.Cmd {
+	if m.refreshInterval <= 0 {
+		return nil
+	}
+	gen := m.backendGen
+	return tea.Tick(m.refreshInterval, func(time.Time) tea.Msg { return refreshTickMsg{gen: gen} })
+}
+
+// fetchUnits re-runs the backend's unit listing in the background, giving
+// up after the load timeout so a hung host cannot stall polling.
+func fetchUnits(m model) tea.Cmd {
+	b, gen, timeout := m.backend, m.backendGen, m.loadTimeout
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		units, err := b.ListUnits(ctx)
+		return unitsRefreshedMsg{gen: gen, units: units, err: err}
+	}
+}
+
+// handleRefreshTick polls for units unless live updates already keep the list current.
+func handleRefreshTick(m model) (model, tea.Cmd) {
+	if m.fleetMode {
+		return m, fetchFleet(m)
+	}
+	if m.liveUpdates {
+		return m, scheduleRefresh(m)
+	}
+	return m, fetchUnits(m)
+}
+
+// applyRefresh diffs units against FullUnitList and swaps them into the Units
+// list, keeping the selected unit, page and filter text where they were.
+func applyRefresh(m model, units []system.Unit) (model, tea.Cmd) {
+	return replaceUnits(m, units, system.ChangedUnits(m.FullUnitList, units))
+}
+
+// replaceUnits is applyRefresh with the units to highlight given; nil
+// highlights none.
+func replaceUnits(m model, units []system.Unit, changed map[string]bool) (model, tea.Cmd) {
+	m.FullUnitList = units
+
+	unitsList := &m.lists[constants.TabUnits]
+
+	// Remember which unit the cursor is on before the items move
+	var selectedKey string
+	if li, ok := unitsList.SelectedItem().(listui.ListItem); ok {
+		selectedKey = li.Unit.Key()
+	}
+
+	visible := visibleUnits(m, units)
+	items := make([]list.Item, 0, len(visible))
+	newIndex := -1
+	for _, u := range visible {
+		if u.Key() == selectedKey {
+			newIndex = len(items)
+		}
+		items = append(items, listui.ListItem{Unit: u, Changed: changed[u.Key()], Marked: m.marked[u.Key()]})
+	}
+
+	cmds := []tea.Cmd{unitsList.SetItems(items)}
+
+	// Filtered lists re-filter asynchronously and keep their cursor; only
+	// chase the selected unit when the list is showing everything.
+	if unitsList.FilterState() == list.Unfiltered && newIndex >= 0 && newIndex != unitsList.Index() {
+		unitsList.Select(newIndex)
+	}
+
+	if len(changed) > 0 {
+		m.highlightGen++
+		gen := m.highlightGen
+		cmds = append(cmds, tea.Tick(highlightDuration, func(time.Time) tea.Msg {
+			return clearHighlightMsg{gen: gen}
+		}))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// clearHighlight drops the Changed mark from every unit in the list.
+func clearHighlight(m model) (model, tea.Cmd) {
+	unitsList := &m.lists[constants.TabUnits]
+	items := unitsList.Items()
+	cleared := make([]list.Item, len(items))
+	for i, item := range items {
+		if li, ok := item.(listui.ListItem); ok && li.Changed {
+			li.Changed = false
+			item = li
+		}
+		cleared[i] = item
+	}
+	return m, unitsList.SetItems(cleared)
+}
@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"systemctltui/internal/system"
+)
+
+// hungBackend never answers ListUnits before its context is done, like a
+// host that stopped responding mid-connection.
+type hungBackend struct {
+	system.Backend
+}
+
+func (hungBackend) ListUnits(ctx context.Context) ([]system.Unit, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestFetchUnitsTimesOut(t *testing.T) {
+	m := NewModel(system.NewFakeExecutor(), hungBackend{}, Config{LoadTimeout: 20 * time.Millisecond})
+
+	done := make(chan unitsRefreshedMsg, 1)
+	go func() { done <- fetchUnits(m)().(unitsRefreshedMsg) }()
+	select {
+	case msg := <-done:
+		if !errors.Is(msg.err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want the deadline", msg.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fetchUnits did not give up")
+	}
+}
@@ -0,0 +1,188 @@
+// package tui
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"systemctltui/internal/listui"
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system"
+)
+
+// knownTargets is the picker's initial entries: the local machine, the
+// configured targets and the one we started on, without duplicates.
+func knownTargets(cfg Config) []system.Target {
+	targets := []system.Target{{}}
+	for _, t := range append(cfg.Targets, cfg.Options.Target) {
+		targets = addTarget(targets, t)
+	}
+	return targets
+}
+
+// addTarget appends t to targets unless it is already there.
+func addTarget(targets []system.Target, t system.Target) []system.Target {
+	for _, known := range targets {
+		if known == t {
+			return targets
+		}
+	}
+	return append(targets, t)
+}
+
+func newTargetInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "user@host or machine:container"
+	ti.CharLimit = 256
+	return ti
+}
+
+// openTargetPicker builds the target list and switches to StateTargetPicker.
+func openTargetPicker(m model) model {
+	items := make([]listui.SimpleListItem, 0, len(m.knownTargets))
+	for _, t := range m.knownTargets {
+		opts := m.options
+		opts.Target = t
+
+		desc := ""
+		if t == m.options.Target {
+			desc = "current"
+		} else if cached, ok := m.unitCache[cacheKey(opts)]; ok {
+			desc = fmt.Sprintf("%d units cached", len(cached))
+		}
+		items = append(items, listui.SimpleListItem{TitleValue: t.String(), DescValue: desc})
+	}
+
+	m.targetList = listui.CreateSimpleList(items)
+	m.targetList.SetShowHelp(false) // the picker footer lists its own keys
+	m.targetList.SetSize(pickerSize(m))
+	m.addingTarget = false
+	m.state = StateTargetPicker
+	return m
+}
+
+// pickerSize is the list size used inside the centered picker box.
+func pickerSize(m model) (int, int) {
+	width, height := m.width/2, m.height/2
+	if width < 30 {
+		width = 30
+	}
+	if height < 5 {
+		height = 5
+	}
+	return width, height
+}
+
+// updateTargetPicker handles messages while choosing a host or container.
+func updateTargetPicker(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.addingTarget {
+		return updateTargetInput(m, msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// While the user is typing a filter, every key belongs to the list
+		if m.targetList.FilterState() == list.Filtering {
+			break
+		}
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc", "q":
+			m.state = StateBrowse
+			return m, nil
+		case "a":
+			m.addingTarget = true
+			m.targetInput.Reset()
+			return m, m.targetInput.Focus()
+		case "enter":
+			item, ok := m.targetList.SelectedItem().(listui.SimpleListItem)
+			if !ok {
+				return m, nil
+			}
+			target, err := system.ParseTarget(item.TitleValue)
+			if err != nil {
+				m.commandOutput = err.Error()
+				m.state = StateOutput
+				return m, nil
+			}
+			if target == m.options.Target && !m.fleetMode {
+				m.state = StateBrowse
+				return m, nil
+			}
+			opts := m.options
+			opts.Target = target
+			return switchBackend(m, opts)
+		}
+
+	case tea.WindowSizeMsg:
+		m = setSize(m, msg.Width, msg.Height)
+		m.targetList.SetSize(pickerSize(m))
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.targetList, cmd = m.targetList.Update(msg)
+	return m, cmd
+}
+
+// updateTargetInput handles typing a new target into the picker.
+func updateTargetInput(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc":
+			m.addingTarget = false
+			m.targetInput.Blur()
+			return m, nil
+		case "enter":
+			target, err := system.ParseTarget(m.targetInput.Value())
+			if err != nil {
+				m.targetInput.SetValue("")
+				m.targetInput.Placeholder = err.Error()
+				return m, nil
+			}
+			m.knownTargets = addTarget(m.knownTargets, target)
+			m = openTargetPicker(m) // rebuild the list with the new entry
+			m.targetList.Select(len(m.targetList.Items()) - 1)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.targetInput, cmd = m.targetInput.Update(msg)
+	return m, cmd
+}
+
+// renderTargetPickerView renders the target picker as a centered box.
+func renderTargetPickerView(m model) string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#5A56E0")).
+		Padding(1, 2)
+
+	footer := "Enter: switch | a: add target | /: filter | Esc: back"
+	body := m.targetList.View()
+	if m.addingTarget {
+		footer = "Enter: add | Esc: cancel"
+		body = lipgloss.JoinVertical(lipgloss.Left, body, "", "New target: "+m.targetInput.View())
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		fmt.Sprintf("Select target (%s scope):", m.options.Scope),
+		"",
+		body,
+		styles.FooterStyle.Render(footer),
+	)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center, // Horizontal alignment
+		lipgloss.Center, // Vertical alignment
+		boxStyle.Render(content),
+	)
+}
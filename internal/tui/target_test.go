@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"systemctltui/internal/system"
+)
+
+// TestSwitchTarget picks a remote host in the target picker and goes back,
+// checking that commands follow the target and that the local units come
+// back from the cache at once.
+func TestSwitchTarget(t *testing.T) {
+	ex := fixtureExecutor(t)
+	ex.On(system.FakeResponse{Stdout: "nginx.service loaded active running Web\n"}, "systemctl", "-H", "root@web1", "list-units", "--all", "--no-legend", "--plain", "--output=json")
+	ex.On(system.FakeResponse{Stdout: "running\n"}, "systemctl", "-H", "root@web1", "is-system-running")
+	m := NewModel(ex, system.NewSystemctlBackend(ex, system.Options{}), Config{Targets: []system.Target{{Host: "root@web1"}}})
+	m = setSize(m, 120, 40)
+	m, _ = send(t, m, runCmd(m.Init())...)
+	local := len(m.FullUnitList)
+
+	// local is first in the picker, web1 below it
+	m, _ = send(t, m, key("t"), key("j"), key("enter"))
+	if m.options.Target.Host != "root@web1" || m.state != StateBrowse {
+		t.Fatalf("target %s in state %v, want browsing root@web1", m.options.Target, m.state)
+	}
+	if got := listedNames(m); !slices.Equal(got, []string{"nginx.service"}) {
+		t.Errorf("listed %q on web1", got)
+	}
+	if _, ok := m.unitCache[cacheKey(system.Options{})]; !ok {
+		t.Error("the local units were not cached")
+	}
+
+	// Back to local: shown from the cache before anything runs
+	calls := len(ex.Calls())
+	m, cmd := switchBackend(m, system.Options{})
+	if m.state != StateBrowse || len(m.FullUnitList) != local {
+		t.Fatalf("state %v with %d units, want the %d cached ones", m.state, len(m.FullUnitList), local)
+	}
+	if len(ex.Calls()) != calls {
+		t.Error("switching back ran commands before showing the cache")
+	}
+	// ... and refreshed in the background
+	m, _ = send(t, m, runCmd(cmd)...)
+	var refreshed bool
+	for _, c := range ex.Calls()[calls:] {
+		if strings.Join(c, " ") == "systemctl list-units --all --no-legend --plain --output=json" {
+			refreshed = true
+		}
+		if slices.Contains(c, "-H") {
+			t.Errorf("ran %q after switching back to local", c)
+		}
+	}
+	if !refreshed {
+		t.Error("the cached list was not refreshed")
+	}
+}
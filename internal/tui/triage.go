@@ -0,0 +1,119 @@
+// package tui
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"systemctltui/internal/system"
+)
+
+// triageErrorLines is how many error-priority journal lines the triage
+// screen shows.
+const triageErrorLines = 20
+
+// triageMsg carries the failure report of one unit, identified by Unit.Key.
+type triageMsg struct {
+	key    string
+	report system.FailureReport
+	err    error
+}
+
+// openTriage shows why unit failed on the output screen, once its report
+// has been gathered in the background.
+func openTriage(m model, unit system.Unit) (model, tea.Cmd) {
+	m.previewCommand = fmt.Sprintf("Why did %s fail?", unit.Name)
+	m.state = StateOutput
+	if unit.Active != "failed" {
+		m.commandOutput = fmt.Sprintf("Unit '%s' is %s, not failed.\n\nPress Enter on it in the Units list for its details.", unit.Name, unit.Active)
+		return m, nil
+	}
+	m.commandOutput = fmt.Sprintf("Unit '%s' failed.\n\nGathering status, error logs and core dumps...", unit.Name)
+	m.triageKey = unit.Key()
+
+	backend, ex, opts, timeout := hostBackend(m, unit.Host), m.executor, hostOptions(m, unit.Host), m.loadTimeout
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		report, err := system.Triage(ctx, backend, ex, opts, unit.Name, triageErrorLines)
+		return triageMsg{key: unit.Key(), report: report, err: err}
+	}
+}
+
+// handleTriage shows a failure report if the output view is still waiting
+// for it.
+func handleTriage(m model, msg triageMsg) model {
+	if m.state != StateOutput || msg.key != m.triageKey {
+		return m // the user has moved on
+	}
+	m.triageKey = ""
+	if msg.err != nil {
+		m.commandOutput = "Failed to load unit details:\n" + msg.err.Error()
+		return m
+	}
+	m.commandOutput = renderTriage(msg.report, time.Now())
+	return m
+}
+
+// renderTriage lays out a failure report: the verdict first, then the
+// timeline, the unit's last errors and its core dumps.
+func renderTriage(r system.FailureReport, now time.Time) string {
+	d := r.Details
+	var b strings.Builder
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%12s: %s\n", label, value)
+		}
+	}
+	stamp := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("Mon 2006-01-02 15:04:05 MST") + "; " + formatDuration(now.Sub(t)) + " ago"
+	}
+
+	fmt.Fprintf(&b, "%s - %s\n", d.Name, d.Description)
+	row("Active", fmt.Sprintf("%s (%s)", d.ActiveState, d.SubState))
+	row("Result", system.DescribeResult(d.Result))
+	row("Main exit", system.DescribeExit(d.ExecMainCode, d.ExecMainStatus))
+	row("Restarts", fmt.Sprint(d.NRestarts))
+
+	b.WriteString("\nTimeline:\n")
+	row("Started", stamp(d.ExecMainStartTimestamp))
+	row("Exited", stamp(d.ExecMainExitTimestamp))
+	row("Deactivated", stamp(d.ActiveExitTimestamp))
+	row("Failed", stamp(d.FailedSince()))
+
+	fmt.Fprintf(&b, "\nLast errors (journalctl -p err, up to %d entries):\n", triageErrorLines)
+	switch {
+	case r.ErrorsErr != nil:
+		b.WriteString("  Could not read the journal: " + r.ErrorsErr.Error() + "\n")
+	case len(r.Errors) == 0:
+		b.WriteString("  No error-priority entries.\n")
+	}
+	for _, e := range r.Errors {
+		for _, line := range strings.Split(e.Message, "\n") {
+			fmt.Fprintf(&b, "  %s %s: %s\n", e.Time.Format("Jan 02 15:04:05"), e.Identifier, line)
+		}
+	}
+
+	b.WriteString("\nCore dumps:\n")
+	switch {
+	case r.CoredumpsErr != nil:
+		b.WriteString("  Unknown: " + r.CoredumpsErr.Error() + "\n")
+	case len(r.Coredumps) == 0:
+		b.WriteString("  None.\n")
+	}
+	for _, c := range r.Coredumps {
+		line := fmt.Sprintf("  %s  PID %d  %s  %s", c.Time, c.PID, c.Signal, c.Exe)
+		if c.Corefile != "" {
+			line += "  (core " + c.Corefile + ")"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
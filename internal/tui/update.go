@@ -2,23 +2,137 @@
 package tui
 
 import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"systemctltui/internal/constants"
 	"systemctltui/internal/listui"
 	"systemctltui/internal/system"
 	"systemctltui/internal/messages" // <--- Import the CORRECT messages package
 	"fmt"
-	"strings"
 )
 
 // Update handles messages and updates the model state.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return syncPager(m.update(msg))
+}
+
+// update does the work of Update; see syncPager for what happens after.
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Background messages are handled the same way in every state
+	switch msg := msg.(type) {
+	case subscribedMsg:
+		if msg.gen != m.backendGen {
+			return m, nil // from a backend we've since switched away from
+		}
+		m.liveUpdates = true
+		return m, waitForUnitEvent(msg.gen, msg.events)
+	case subscriptionFailedMsg:
+		if msg.gen == m.backendGen {
+			m.liveUpdates = false
+		}
+		return m, nil
+	case unitEventMsg:
+		if msg.gen != m.backendGen {
+			return m, nil
+		}
+		failedBefore := system.CountHealth(m.FullUnitList).Failed
+		var cmd, healthCmd tea.Cmd
+		m, cmd = applyUnitEvent(m, msg.event)
+		m, healthCmd = unitsUpdated(m, system.CountHealth(m.FullUnitList).Failed != failedBefore)
+		return withPane(m, tea.Batch(cmd, healthCmd, waitForUnitEvent(msg.gen, msg.events)))
+	case refreshTickMsg:
+		if msg.gen != m.backendGen {
+			return m, nil
+		}
+		return handleRefreshTick(m)
+	case unitsRefreshedMsg:
+		if msg.gen != m.backendGen {
+			return m, nil
+		}
+		var cmd, healthCmd tea.Cmd
+		m.refreshErr = msg.err
+		if msg.err == nil {
+			m, cmd = applyRefresh(m, msg.units)
+			m, healthCmd = unitsUpdated(m, true)
+		}
+		// Keep the previous list on errors and try again next tick
+		return withPane(m, tea.Batch(cmd, healthCmd, scheduleRefresh(m)))
+	case fleetHostMsg:
+		if msg.gen != m.backendGen {
+			return m, nil
+		}
+		next, cmd := handleFleetHost(m, msg)
+		next, healthCmd := unitsUpdated(next, false)
+		return withPane(next, tea.Batch(cmd, healthCmd))
+	case fleetDoneMsg:
+		if msg.gen != m.backendGen {
+			return m, nil
+		}
+		next, cmd := handleFleetDone(m, msg)
+		// Ask every host for its state once, not per answer
+		next, healthCmd := unitsUpdated(next, true)
+		return withPane(next, tea.Batch(cmd, healthCmd))
+	case systemStatesMsg:
+		return handleSystemStates(m, msg), nil
+	case unitDetailsMsg:
+		return handleUnitDetails(m, msg), nil
+	case messages.CommandOutputMsg:
+		return handleCommandOutput(m, msg)
+	case messages.CommandFinishedMsg:
+		return handleJobFinished(m, msg)
+	case jobsTickMsg:
+		return handleJobsTick(m)
+	case managerJobsTickMsg:
+		if msg.seq != m.managerJobsSeq {
+			return m, nil
+		}
+		return m, fetchManagerJobs(m, true)
+	case managerJobsMsg:
+		return handleManagerJobs(m, msg)
+	case paneTickMsg:
+		return m, handlePaneTick(m, msg)
+	case paneLoadedMsg:
+		return handlePaneLoaded(m, msg), nil
+	case logsLoadedMsg:
+		return handleLogsLoaded(m, msg), nil
+	case logFollowMsg:
+		return handleLogFollow(m, msg)
+	case triageMsg:
+		return handleTriage(m, msg), nil
+	case bootsLoadedMsg:
+		return handleBootsLoaded(m, msg), nil
+	case clearHighlightMsg:
+		if msg.gen != m.highlightGen {
+			return m, nil // a newer refresh owns the highlight now
+		}
+		return clearHighlight(m)
+	case facetMatchesMsg:
+		return handleFacetMatches(m, msg)
+	case list.FilterMatchesMsg:
+		// Re-filtering after a live update may finish while another view
+		// is open; it always belongs to the Units list in that case.
+		if m.state != StateBrowse {
+			var cmd tea.Cmd
+			m.lists[constants.TabUnits], cmd = m.lists[constants.TabUnits].Update(msg)
+			return m, cmd
+		}
+	}
+
 	// Handle messages based on the current state
 	switch m.state {
+	case StateLoading:
+		return withPane(updateLoading(m, msg))
+	case StateTargetPicker:
+		return updateTargetPicker(m, msg)
 	case StateBrowse:
-		return updateBrowse(m, msg)
+		return withPane(updateBrowse(m, msg))
 	case StatePreview:
 		return updatePreview(m, msg)
+	case StateFiltering:
+		return updateFiltering(m, msg)
+	case StateLogs:
+		return updateLogs(m, msg)
 	case StateOutput:
 		return updateOutput(m, msg)
 	default:
@@ -37,48 +151,111 @@ func updateBrowse(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// While the user is typing a filter, every key belongs to the list
+	if _, ok := msg.(tea.KeyMsg); ok && m.lists[m.activeTab].FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.lists[m.activeTab], cmd = m.lists[m.activeTab].Update(msg)
+		return m, cmd
+	}
+
 	// Handle key presses specific to Browse
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.activeTab == constants.TabFailed {
+			// Bulk actions on the failed units, see failed.go
+			if next, cmd, ok := updateFailedKeys(m, msg); ok {
+				return next, cmd
+			}
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
+		case "U":
+			// Switch between the system and the user service manager
+			// ("u" is the lists' page-up key)
+			return toggleScope(m)
+		case "t":
+			// Choose a remote host or container to manage
+			return openTargetPicker(m), nil
+		case "F":
+			// Show units from every known target in one list, or go back
+			return toggleFleet(m)
+		case "!":
+			if m.fleetMode {
+				return toggleFleetFailedOnly(m)
+			}
 		case "tab":
 			m.activeTab = (m.activeTab + 1) % len(m.tabs)
+			if m.activeTab == constants.TabJobs {
+				return watchManagerJobs(m)
+			}
             // Optional: Reset filter or scroll when switching tabs
             // m.lists[m.activeTab].ResetFilter()
             // m.lists[m.activeTab].GotoTop()
 		case "shift+tab":
 			m.activeTab = (m.activeTab - 1 + len(m.tabs)) % len(m.tabs)
+			if m.activeTab == constants.TabJobs {
+				return watchManagerJobs(m)
+			}
              // Optional: Reset filter or scroll when switching tabs
             // m.lists[m.activeTab].ResetFilter()
             // m.lists[m.activeTab].GotoTop()
 		case "f1":
 			m.showHelp = true
+		case " ":
+			if m.activeTab == constants.TabUnits {
+				// Mark the unit for a batch action
+				return toggleMark(m)
+			}
+		case "C":
+			if m.activeTab == constants.TabUnits {
+				return clearMarks(m)
+			}
+		case "L":
+			if m.activeTab == constants.TabUnits {
+				// Read the highlighted unit's journal
+				if li, ok := m.lists[constants.TabUnits].SelectedItem().(listui.ListItem); ok {
+					return openLogs(m, li.Unit)
+				}
+			}
+		case "w":
+			if m.activeTab == constants.TabUnits {
+				// Why did the highlighted unit fail?
+				if li, ok := m.lists[constants.TabUnits].SelectedItem().(listui.ListItem); ok {
+					return openTriage(m, li.Unit)
+				}
+			}
+		case "v":
+			if m.activeTab == constants.TabUnits {
+				// Narrow the list by type, load, active and sub state
+				// ("f" is the lists' page-down key)
+				return openFilterDialog(m)
+			}
+		case "x":
+			if m.activeTab == constants.TabJobs {
+				// Cancel the highlighted job
+				if j, ok := m.lists[constants.TabJobs].SelectedItem().(job); ok {
+					return cancelJob(m, j.id)
+				}
+			}
 		case "enter":
 			// Handle selection based on the active tab
 			switch m.activeTab {
 			case constants.TabOptions:
 				selectedItem := m.lists[m.activeTab].SelectedItem()
                 if selectedItem != nil {
-                     optItem := selectedItem.(listui.ListItem) // Type assertion
+                     optItem := selectedItem.(listui.SimpleListItem) // Type assertion
                      // For --version, could execute and show output
                      if optItem.Title() == "--version" {
                           m.selectedCommand = "--version" // Store command name
-                          m.previewCommand = "systemctl --version" // Full command string for output view
                           m.selectedUnit = "" // No unit
-                          m.state = StateOutput // Go directly to output state
-                          m.commandOutput = "Running 'systemctl --version'..." // Initial running message
-                          // Execute the command
-                          return m, system.SystemctlCommand(m.selectedCommand, m.selectedUnit) // Use the helper
+                          // Execute the command, streaming into the output state
+                          return submitSpec(m, actionSpec(m, m.selectedCommand))
                      } else if optItem.Title() == "-h" || optItem.Title() == "--help" {
                          // For help, could execute systemctl --help and show output
                          m.selectedCommand = "--help"
-                         m.previewCommand = "systemctl --help"
                          m.selectedUnit = ""
-                         m.state = StateOutput
-                         m.commandOutput = "Running 'systemctl --help'..."
-                         return m, system.SystemctlCommand(m.selectedCommand, m.selectedUnit)
+                         return submitSpec(m, actionSpec(m, m.selectedCommand))
                      }
                      // For other options, maybe show description or error?
                      m.commandOutput = fmt.Sprintf("Info for option: %s - %s (Execution not implemented)", optItem.Title(), optItem.Description())
@@ -94,39 +271,33 @@ func updateBrowse(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			case constants.TabCommands:
 				selectedItem := m.lists[m.activeTab].SelectedItem()
 				if selectedItem != nil {
-                    cmdItem := selectedItem.(listui.ListItem) // Type assertion
+                    cmdItem := selectedItem.(listui.SimpleListItem) // Type assertion
                     m.selectedCommand = cmdItem.Title() // Store the selected command
-
-                    // Construct the command to preview
-                    // We need the selected unit from the Units tab.
-                    // First, check if the command *requires* a unit.
-                    needsUnit := true
-                    switch m.selectedCommand {
-                        case "status", "list-units", "list-timers", "list-sockets", "list-jobs", "list-dependencies", "list-unit-files", "is-active", "is-enabled", "is-failed":
-                            needsUnit = false
-                        default:
-                            needsUnit = true // Assume most other commands need a unit
+                    m.batchUnits = nil
+                    m.optionBuilder = newOptionBuilder(m.selectedCommand)
+
+                    // With units marked, batch verbs run on all of them
+                    if units := markedUnits(m); len(units) > 0 && batchVerbs[m.selectedCommand] {
+                        m.batchUnits = units
+                        m.previewCommand = batchPreview(m, m.selectedCommand, nil, units)
+                        m.state = StatePreview
+                        return m, nil
                     }
 
-
-                    if needsUnit && m.selectedUnit == "" {
-                        // If the command requires a unit but none is selected
-                        m.commandOutput = fmt.Sprintf("Command '%s' requires a unit. Please select a unit first in the Units tab.", m.selectedCommand)
+                    // Construct the command to preview, with the unit selected
+                    // in the Units tab if the verb takes one
+                    spec := actionSpec(m, m.selectedCommand)
+                    if err := spec.Validate(); err != nil {
+                        // e.g. the command requires a unit but none is selected
+                        m.commandOutput = fmt.Sprintf("%s. Please select a unit first in the Units tab.", err)
                         m.showHelp = false // Hide help if it was showing
                         m.state = StateOutput // Go directly to output state
                         return m, nil // Stay in output state until keypress
                     }
 
-                    // Construct the preview command string
-                    m.previewCommand = "systemctl " + m.selectedCommand
-                    if m.selectedUnit != "" && needsUnit { // Only append unit if needed and available
-                         m.previewCommand += " " + m.selectedUnit
-                    } else if m.selectedUnit != "" && !needsUnit && (m.selectedCommand == "status" || m.selectedCommand == "is-active" || m.selectedCommand == "is-enabled" || m.selectedCommand == "is-failed") {
-                        // Special case: status/is-active etc. *can* take a unit, so include it if selected
-                         m.previewCommand += " " + m.selectedUnit
-                    }
-                    // else: command doesn't need a unit, selected unit ignored for preview string
-
+                    // The preview shows exactly what will run
+                    m.previewSpec = spec
+                    m.previewCommand = spec.String()
 
                     m.state = StatePreview // Change state to preview
                     return m, nil // Stay in preview state
@@ -142,43 +313,34 @@ func updateBrowse(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				selectedItem := m.lists[m.activeTab].SelectedItem()
 				if selectedItem != nil {
                     unitItem := selectedItem.(listui.ListItem) // Type assertion
-                    m.selectedUnit = unitItem.Title() // Store the selected unit name
-
-                    // Optional: Show confirmation or indicate unit is selected
-                    m.commandOutput = fmt.Sprintf("Unit '%s' selected.", m.selectedUnit)
-                    m.state = StateOutput // Go to output state briefly
-                    return m, nil // Stay in output state
+                    m.selectedUnit = unitItem.Unit.Name // Store the selected unit name
+                    m.selectedHost = unitItem.Unit.Host // and its host in the fleet view
+
+                    // Confirm the selection and show what systemd knows about the unit
+                    m.previewCommand = actionSpec(m, "show").String()
+                    m.commandOutput = fmt.Sprintf("Unit '%s' selected.\n\nLoading details...", unitItem.Title())
+                    m.detailsKey = unitItem.Unit.Key()
+                    m.state = StateOutput
+                    return m, fetchDetails(m, unitItem.Unit)
 				}
                  // If no item selected in Units tab
                 m.commandOutput = "Select a unit using Enter."
                 m.state = StateOutput
                 return m, nil
+
+			case constants.TabJobs:
+				// Reopen the output of the highlighted job
+				if j, ok := m.lists[m.activeTab].SelectedItem().(job); ok {
+					return openJob(m, j.id), nil
+				}
+				return m, nil
 			}
 
 		}
 
 	case tea.WindowSizeMsg:
         // Update window size and resize lists
-		m.width, m.height = msg.Width, msg.Height
-		// Recalculate and set list sizes based on available space
-		overheadHeight := 4 // Adjust this as needed
-
-		listItemsViewportHeight := m.height - overheadHeight
-		if listItemsViewportHeight < 0 { listItemsViewportHeight = 0 }
-
-		listTotalWidth := m.width
-
-		for i := range m.lists {
-			m.lists[i].SetSize(listTotalWidth, listItemsViewportHeight)
-		}
-		return m, nil // No command needed for size change
-
-	// This case handles the message when a command finishes executing asynchronously.
-    // It can arrive while we are in StateOutput.
-	case messages.CommandFinishedMsg: // <--- Use messages.CommandFinishedMsg from the correct package
-        // This message is handled in updateOutput, but included here for robustness
-        // if the state flow was different. With current flow, it's handled in updateOutput.
-        return updateOutput(m, msg)
+		return setSize(m, msg.Width, msg.Height), nil // No command needed for size change
 
 	} // <--- Closing brace for the switch on msg type
 
@@ -190,10 +352,80 @@ func updateBrowse(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 
+// setSize records the terminal size and resizes the lists to fit.
+func setSize(m model, width, height int) model {
+	m.width, m.height = width, height
+	// Recalculate and set list sizes based on available space
+	overheadHeight := 5 // Tabs (2), footer (2) and the health header above the tabs
+
+	listItemsViewportHeight := m.height - overheadHeight
+	if listItemsViewportHeight < 0 { listItemsViewportHeight = 0 }
+
+	listTotalWidth := m.width
+
+	for i := range m.lists {
+		m.lists[i].SetSize(listTotalWidth, listItemsViewportHeight)
+	}
+	// The Units list shares its row with the detail pane
+	m.lists[constants.TabUnits].SetWidth(unitsListWidth(m))
+	// The filter dialog leaves room for its frame, summary and key hints
+	m.filterList.SetSize(max(0, min(60, m.width-6)), max(0, listItemsViewportHeight-8))
+	m = sizeLogView(m)
+	return m
+}
+
+// updateLoading handles messages while the initial unit list is loading.
+func updateLoading(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case unitsLoadedMsg:
+		if msg.err != nil {
+			// Stay on the loading screen and offer a retry
+			m.loadErr = msg.err
+			return m, nil
+		}
+		m.loadErr = nil
+		m.state = StateBrowse
+		var cmd, healthCmd tea.Cmd
+		m, cmd = setUnits(m, msg.units)
+		m, healthCmd = unitsUpdated(m, true)
+		// Only now start keeping the list fresh
+		var liveCmd tea.Cmd
+		m, liveCmd = startLiveUpdates(m)
+		return m, tea.Batch(cmd, healthCmd, liveCmd, scheduleRefresh(m))
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "r":
+			if m.loadErr != nil {
+				m.loadErr = nil
+				if m.fleetMode {
+					return m, tea.Batch(m.spinner.Tick, fetchFleet(m))
+				}
+				return m, tea.Batch(m.spinner.Tick, loadUnits(m.backend, m.loadTimeout))
+			}
+		}
+
+	case tea.WindowSizeMsg:
+		return setSize(m, msg.Width, msg.Height), nil
+	}
+	return m, nil
+}
+
 // updatePreview handles messages when the command preview is shown.
 func updatePreview(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Keys for the verb's options change the previewed command in place
+		if next, cmd, ok := updateOptionKeys(m, msg); ok {
+			return next, cmd
+		}
 		switch msg.String() {
 		case "enter":
 			// User confirms execution
@@ -204,22 +436,19 @@ func updatePreview(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
                  return m, nil
             }
 
-			m.state = StateOutput // Change state to show output view
-            m.commandOutput = "Running '" + m.previewCommand + "'..." // Show a running message immediately
-
-            // Execute the command asynchronously
-            // Need to parse m.previewCommand into command and args
-            // Use strings.Fields for basic parsing (handles spaces)
-            parts := strings.Fields(m.previewCommand)
-            if len(parts) == 0 {
-                 m.commandOutput = "Error: No command to execute."
-                 return m, nil // Stay in output state with error
+            if len(m.batchUnits) > 0 {
+                // One job per marked unit, with a summary of all of them
+                units := m.batchUnits
+                m.batchUnits = nil
+                return submitBatch(m, m.selectedCommand, m.optionBuilder.args(), units)
             }
-            cmd := parts[0] // e.g., "systemctl"
-            args := parts[1:] // e.g., ["status", "nginx.service"]
 
-            // Call the async command execution function
-			return m, system.ExecuteCommandAsync(cmd, args...) // Return the async command
+            // Execute the previewed spec asynchronously, streaming its output.
+            // systemctl invocations go through the backend (which may be D-Bus)
+            // of the host a unit picked in the fleet view lives on.
+            spec := m.previewSpec
+            m.previewSpec = system.CommandSpec{}
+            return submitSpec(m, spec)
 
 
 		case "esc":
@@ -227,6 +456,8 @@ func updatePreview(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = StateBrowse // Go back to Browse state
 			m.selectedCommand = "" // Clear command state
 			m.previewCommand = ""
+			m.previewSpec = system.CommandSpec{}
+			m.batchUnits = nil
 			// Keep selectedUnit
 			return m, nil
 		}
@@ -234,9 +465,8 @@ func updatePreview(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 
     // Handle WindowSizeMsg in preview state as well
      case tea.WindowSizeMsg:
-        m.width, m.height = msg.Width, msg.Height
-        // No list to resize, but model dimensions are updated for rendering preview box
-        return m, nil
+        // Resize the lists too, so they fit when the user goes back to browsing
+        return setSize(m, msg.Width, msg.Height), nil
 
 	} // <--- Add closing brace for the switch on msg type
 	return m, nil
@@ -246,30 +476,42 @@ func updatePreview(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 func updateOutput(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
     // Handle messages specific to output state
 	switch msg := msg.(type) {
-    // This case handles the message when the async command finishes
-	case messages.CommandFinishedMsg: // <--- Use messages.CommandFinishedMsg from the correct package
-        // Command has finished, update the output
-		m.commandOutput = msg.Output
-		if msg.Err != nil {
-			// Prepend "Error:" or append error details clearly
-			m.commandOutput += "\n--- ERROR ---\n" + msg.Err.Error() // Append error details
-		}
-        // No state change, stay in output state showing the result
-		return m, nil
-
     case tea.KeyMsg:
-        // Any keypress dismisses the output view
-        m.state = StateBrowse // Go back to Browse
-        m.selectedCommand = ""
-        m.previewCommand = ""
-        m.commandOutput = "" // Clear the output
-        // Keep selectedUnit
-        return m, nil
+        if msg.String() == "ctrl+c" {
+            if viewedJobRunning(m) {
+                // Abort the command rather than the whole program
+                return cancelJob(m, m.viewedJob)
+            }
+            return m, tea.Quit
+        }
+        if !m.pager.searching && msg.String() == "x" && viewedJobRunning(m) {
+            // Same as ctrl+c, like x on the Jobs tab
+            return cancelJob(m, m.viewedJob)
+        }
+        if !m.pager.searching && (msg.String() == "esc" || msg.String() == "q") {
+            // Close the output view; a running job carries on in the background
+            m.viewedJob, m.viewedBatch = 0, 0
+            m.state = StateBrowse // Go back to Browse
+            m.selectedCommand = ""
+            m.previewCommand = ""
+            m.commandOutput = "" // Clear the output
+            // Keep selectedUnit
+            return m, nil
+        }
+        // Everything else scrolls or searches
+        return updatePagerKeys(m, msg)
+
+    default:
+        if m.pager.searching {
+            // Keep the search prompt's cursor blinking
+            var cmd tea.Cmd
+            m.pager.search, cmd = m.pager.search.Update(msg)
+            return m, cmd
+        }
 
     case tea.WindowSizeMsg:
-        m.width, m.height = msg.Width, msg.Height
-        // No list to resize, but model dimensions are updated for rendering output box
-        return m, nil
+        // Resize the lists too, so they fit when the user goes back to browsing
+        return setSize(m, msg.Width, msg.Height), nil
 
 	} // <--- Add closing brace for the switch on msg type
 	// Do not delegate to list or handle other messages in output state
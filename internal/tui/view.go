@@ -3,10 +3,13 @@ package tui
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"systemctltui/internal/constants"
-	"systemctltui/internal/styles" // <--- Check this import path matches your module name
+	"systemctltui/internal/styles"
+	"systemctltui/internal/system" // <--- Check this import path matches your module name
 )
 
 // View renders the TUI based on the current state.
@@ -18,10 +21,18 @@ func (m model) View() string {
 
 	// Render different views based on state
 	switch m.state {
+	case StateLoading:
+		return renderLoadingView(m)
+	case StateTargetPicker:
+		return renderTargetPickerView(m)
 	case StateBrowse: // Use StateBrowse
 		return renderBrowseView(m) // Use renderBrowseView
 	case StatePreview:
 		return renderPreviewView(m)
+	case StateFiltering:
+		return renderFilterView(m)
+	case StateLogs:
+		return renderLogsView(m)
 	case StateOutput:
 		return renderOutputView(m)
 	default:
@@ -30,44 +41,134 @@ func (m model) View() string {
 	}
 }
 
+// renderLoadingView renders the spinner shown while units load, or the
+// error and retry hint if loading failed.
+func renderLoadingView(m model) string {
+	var content string
+	if m.loadErr != nil {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			"Failed to load units:",
+			m.loadErr.Error(),
+			"",
+			styles.FooterStyle.Render("r: retry | q: quit"),
+		)
+	} else {
+		source := fmt.Sprintf("the %s (%s)", managerLabel(m.options), m.backend.Name())
+		if m.fleetMode {
+			source = fmt.Sprintf("%d targets", len(m.knownTargets))
+		}
+		content = fmt.Sprintf("%s Loading units from %s...", m.spinner.View(), source)
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center, // Horizontal alignment
+		lipgloss.Center, // Vertical alignment
+		content,
+	)
+}
+
 // renderBrowseView renders the standard tab/list view.
 func renderBrowseView(m model) string { // Use renderBrowseView
 	// Render the header (tabs)
-	header := styles.RenderTabs(m.tabs, m.activeTab)
+	scopeLabel := m.options.Scope.String()
+	if m.fleetMode {
+		scopeLabel = "fleet · " + scopeLabel
+	}
+	header := lipgloss.JoinVertical(lipgloss.Left,
+		renderHealthHeader(m),
+		styles.RenderTabs(m.tabs, m.activeTab, scopeLabel))
 
 	// Render the body (the active list)
 	// The list view should respect the size constraints set by SetSize in Update.
 	body := m.lists[m.activeTab].View()
+	if m.activeTab == constants.TabUnits {
+		// Make room for the fleet status line and filter chips above the list
+		var above []string
+		if m.fleetMode {
+			above = append(above, styles.FooterStyle.UnsetPaddingTop().MaxWidth(m.width).Render(fleetStatusLine(m)))
+		}
+		if chips := renderFilterChips(m); chips != "" {
+			above = append(above, chips)
+		}
+		if len(above) > 0 {
+			statusLines := lipgloss.JoinVertical(lipgloss.Left, above...)
+			unitsList := m.lists[constants.TabUnits]
+			unitsList.SetHeight(unitsList.Height() - lipgloss.Height(statusLines))
+			body = lipgloss.JoinVertical(lipgloss.Left, statusLines, unitsList.View())
+		}
+	}
+	if m.activeTab == constants.TabJobs {
+		// systemd's own queue goes below our jobs
+		section := styles.FooterStyle.MaxWidth(m.width).Render(managerJobsSection(m))
+		jobsList := m.lists[constants.TabJobs]
+		jobsList.SetHeight(jobsList.Height() - lipgloss.Height(section))
+		body = lipgloss.JoinVertical(lipgloss.Left, jobsList.View(), section)
+	}
+	if m.activeTab == constants.TabUnits && paneVisible(m) {
+		listWidth := unitsListWidth(m)
+		body = lipgloss.NewStyle().Width(listWidth).Render(body)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, renderDetailPane(m, m.width-listWidth, lipgloss.Height(body)))
+	}
 
 	// Render the footer (help/info)
 	var footerText string
 	if m.showHelp {
 		footerText = "Help: Press any key to return."
 	} else {
-		footerText = "Tab/Shift+Tab: switch tabs | ↑/↓: navigate | F1: help | q: quit"
+		target := m.options.Target.String()
+		if m.fleetMode {
+			target = "fleet"
+		}
+		footerText = fmt.Sprintf("Target: %s | Tab/Shift+Tab: switch tabs | ↑/↓: navigate | U: system/user | t: target | F: fleet | F1: help | q: quit", target)
 		if m.activeTab == constants.TabCommands {
 			footerText += " | Enter: preview/run"
             // Add info about selected unit if any
-            if m.selectedUnit != "" {
-                footerText += fmt.Sprintf(" (Unit: %s)", m.selectedUnit)
+            if len(m.marked) > 0 {
+                footerText += fmt.Sprintf(" (%d marked units)", len(m.marked))
+            } else if m.selectedUnit != "" {
+                footerText += fmt.Sprintf(" (Unit: %s)", selectedUnitLabel(m))
             } else {
                  footerText += " (No unit selected - some commands may fail)"
             }
 
 		} else if m.activeTab == constants.TabUnits {
-            footerText += " | Enter: select unit" // Indicate Enter selects the unit
+            footerText += " | Enter: select unit | Space: mark | v: filter | L: logs | w: why failed" // Indicate Enter selects the unit
+            if len(m.marked) > 0 {
+                footerText += fmt.Sprintf(" | %d marked (C: clear)", len(m.marked))
+            }
+            if m.fleetMode {
+                footerText += " | !: failed only"
+            }
         } else if m.activeTab == constants.TabOptions {
              footerText += " | Enter: info/run" // Indicate Enter shows info for options
+        } else if m.activeTab == constants.TabJobs {
+             footerText += " | Enter: show output | x: cancel job"
+        } else if m.activeTab == constants.TabFailed {
+            footerText += " | Enter/w: why failed | L: logs | Space: mark"
+            // Bulk actions take the marked failed units, or all of them
+            footerText += fmt.Sprintf(" | r: restart | R: reset-failed (%d units)", len(failedTargets(m)))
+        }
+        if m.activeTab == constants.TabUnits {
+            if m.liveUpdates {
+                footerText += " | ● live"
+            } else if m.refreshErr != nil {
+                footerText += fmt.Sprintf(" | refresh failed: %v", m.refreshErr)
+            } else if m.refreshInterval > 0 {
+                footerText += fmt.Sprintf(" | refresh: %s", m.refreshInterval)
+            }
         }
          // Add currently selected unit regardless of tab (useful context)
         if m.selectedUnit != "" {
              // Avoid showing it twice if already in commands tab
              if m.activeTab != constants.TabCommands {
-                 footerText += fmt.Sprintf(" | Selected Unit: %s", m.selectedUnit)
+                 footerText += fmt.Sprintf(" | Selected Unit: %s", selectedUnitLabel(m))
              }
         }
 	}
-	footer := styles.FooterStyle.Render(footerText)
+	footer := styles.FooterStyle.MaxWidth(m.width).Render(footerText)
 
 	// Use lipgloss.JoinVertical to stack header, body, and footer explicitly.
 	layout := lipgloss.JoinVertical(
@@ -87,6 +188,34 @@ func renderBrowseView(m model) string { // Use renderBrowseView
 	return layout
 }
 
+// selectedUnitLabel names the selected unit, with its host in the fleet view.
+func selectedUnitLabel(m model) string {
+	if m.selectedHost != "" {
+		return m.selectedHost + ": " + m.selectedUnit
+	}
+	return m.selectedUnit
+}
+
+// previewNotes are the warnings and hints the verb's catalogue entry calls
+// for, shown under the previewed command.
+func previewNotes(m model) []string {
+    verb, _ := system.LookupVerb(m.selectedCommand)
+    needsRoot := m.previewSpec.NeedsRoot
+    if len(m.batchUnits) > 0 {
+        needsRoot = m.options.Scope == system.ScopeSystem && !verb.ReadOnly
+    }
+
+    var notes []string
+    if verb.Destructive {
+        notes = append(notes, styles.WarningStyle.Render(fmt.Sprintf("Warning: '%s' is destructive: %s.", verb.Name, strings.ToLower(verb.Description))))
+    }
+    if needsRoot && os.Geteuid() != 0 {
+        // Not fatal: polkit may still allow it after asking
+        notes = append(notes, "Needs root privileges; expect an authentication prompt or a permission error.")
+    }
+    return notes
+}
+
 // renderPreviewView renders the command preview screen.
 func renderPreviewView(m model) string {
     // Style for the preview box
@@ -96,13 +225,19 @@ func renderPreviewView(m model) string {
         Padding(1, 2)
 
     // Content of the preview
-    previewContent := lipgloss.JoinVertical(
-        lipgloss.Left,
+    lines := []string{
         "Command Preview:",
         styles.TabActiveStyle.Render(m.previewCommand), // Style the command string
         "", // Empty line for spacing
-        "Press Enter to Execute, Esc to Cancel",
-    )
+    }
+    if notes := previewNotes(m); len(notes) > 0 {
+        lines = append(append(lines, notes...), "")
+    }
+    if form := renderOptionBuilder(m.optionBuilder); len(form) > 0 {
+        lines = append(append(lines, form...), "")
+    }
+    lines = append(lines, "Press Enter to Execute, Esc to Cancel")
+    previewContent := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
     // Render the content within the styled box
     renderedPreview := previewStyle.Render(previewContent)
@@ -126,7 +261,8 @@ func renderPreviewView(m model) string {
         renderedPreview,
     )
 }
-// renderOutputView renders the command output screen.
+// renderOutputView renders the command output screen: the command that was
+// run above a scrollable pager filling the rest of the terminal.
 func renderOutputView(m model) string {
 	// Style for the output box
 	outputStyle := lipgloss.NewStyle().
@@ -135,80 +271,15 @@ func renderOutputView(m model) string {
 		Padding(1, 2) // Add padding inside the border: 1 vert, 2 horiz
 
 	// Command text line
-	commandLine := styles.TabActiveStyle.Render("> " + m.previewCommand) // Style the command that was run
-
-	// Calculate available height for the output content area within the box
-	// Total screen height - (space taken by outputStyle's vertical padding/border) - commandLineHeight - footerHeight - buffer
-	// --- Use hardcoded vertical overhead based on Padding(1,2) and 1-unit border ---
-	// Vertical space = Top Padding (1) + Bottom Padding (1) + Top Border (1) + Bottom Border (1) = 4
-	borderAndPaddingHeight := 4
-	// -----------------------------------------------------------------------------
-
-	// Use a fixed footer height estimate or calculate it if complex
-	outputFooterEstimateHeight := 1 // "Press any key..." line
+	commandLine := styles.TabActiveStyle.MaxWidth(m.width - pagerChromeWidth).Render("> " + m.previewCommand) // Style the command that was run
 
-	availableOutputHeight := m.height - borderAndPaddingHeight - lipgloss.Height(commandLine) - outputFooterEstimateHeight - 1 // Subtract components and a small buffer
-
-	if availableOutputHeight < 0 {
-		availableOutputHeight = 0
-	}
-
-	// Style for the actual output text block - constraints its size
-	outputContentStyle := lipgloss.NewStyle().
-		// --- Use hardcoded horizontal overhead based on Padding(1,2) and 1-unit border ---
-		// Horizontal space = Left Padding (2) + Right Padding (2) + Left Border (1) + Right Border (1) = 6
-		MaxWidth(m.width - 6).
-		// ---------------------------------------------------------------------------------
-		MaxHeight(availableOutputHeight) // Max height based on calculation
-	// Add word wrapping if needed
-	// WordWrap(true) // You might need to import github.com/muesli/reflow/wordwrap for this
-
-
-	// Render the output content, respecting the size constraints
-	outputContent := outputContentStyle.Render(m.commandOutput)
-
-	// Combine the command line and the output content vertically
-	fullOutput := lipgloss.JoinVertical(
-		lipgloss.Left, // Align left
-		commandLine,
-		outputContent,
-	)
-
-	// Footer instruction
-	outputFooter := styles.FooterStyle.Render("Press any key to return.")
-
-	// Stack the combined output and the footer within the box style
+	// The pager is sized to fill the box in syncPager
 	boxContent := lipgloss.JoinVertical(
 		lipgloss.Left, // Align left
-		fullOutput,
-		outputFooter,
+		commandLine,
+		m.pager.view.View(),
+		renderPagerFooter(m),
 	)
 
-	// Render the box, which automatically wraps its content
-	renderedOutputBox := outputStyle.Render(boxContent)
-
-	// Calculate padding needed to center the box
-	outputWidth := lipgloss.Width(renderedOutputBox)
-	outputHeight := lipgloss.Height(renderedOutputBox)
-
-
-	hPadding := (m.width - outputWidth) / 2
-	vPadding := (m.height - outputHeight) / 2
-
-	if hPadding < 0 {
-		hPadding = 0
-	}
-	if vPadding < 0 {
-		vPadding = 0
-	}
-
-
-	// Place the rendered box centered on the screen
-	return lipgloss.Place(
-		m.width,
-		m.height,
-		lipgloss.Center, // Horizontal alignment
-		lipgloss.Center, // Vertical alignment
-		renderedOutputBox,
-	)
+	return outputStyle.Render(boxContent)
 }